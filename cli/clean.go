@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"tf-generator/generate"
+)
+
+type CleanCommand struct {
+	fs *flag.FlagSet
+	in io.Reader
+
+	files    generateFilesFlags
+	yes      bool
+	dirPaths []string
+}
+
+// NewCleanCommand sub-command to delete generated files that no `generate{}` block produces anymore
+func NewCleanCommand() *CleanCommand {
+	c := &CleanCommand{
+		fs: flag.NewFlagSet("clean", flag.ContinueOnError),
+		in: os.Stdin,
+	}
+
+	c.files.register(c.fs)
+	c.fs.BoolVar(&c.yes, "yes", false, "delete orphaned files without asking for confirmation")
+
+	return c
+}
+
+func (c *CleanCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *CleanCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.dirPaths = c.fs.Args()
+	return nil
+}
+
+func (c *CleanCommand) Run() error {
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+	return generate.Clean(filePaths, 0, c.confirm)
+}
+
+// confirm lists the orphaned files and asks whether they should be deleted
+func (c *CleanCommand) confirm(orphans []string) (bool, error) {
+	fmt.Println("The following generated files are not produced by any generate block anymore:")
+	for _, orphan := range orphans {
+		fmt.Printf("  %s\n", orphan)
+	}
+	if c.yes {
+		return true, nil
+	}
+
+	fmt.Print("Delete these files? [y/N] ")
+	answer, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Println("Nothing was deleted.")
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"tf-generator/generate"
+)
+
+type DependentsCommand struct {
+	fs  *flag.FlagSet
+	out io.Writer
+
+	file        string
+	dir         string
+	parallelism parallelismFlag
+	paths       []string
+}
+
+// NewDependentsCommand sub-command to list the generate files and outputs that depend on the given files
+func NewDependentsCommand() *DependentsCommand {
+	c := &DependentsCommand{
+		fs:  flag.NewFlagSet("dependents", flag.ContinueOnError),
+		out: os.Stdout,
+	}
+
+	c.fs.StringVar(&c.file, "file", "tf-generator.hcl", "name of the generate files")
+	c.fs.StringVar(&c.dir, "dir", ".", "directory searched recursively for generate files")
+	c.parallelism.register(c.fs)
+
+	return c
+}
+
+func (c *DependentsCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *DependentsCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if err := c.parallelism.validate(); err != nil {
+		return err
+	}
+
+	c.paths = c.fs.Args()
+	if len(c.paths) == 0 {
+		return fmt.Errorf("missing file to find the dependents of")
+	}
+	return nil
+}
+
+func (c *DependentsCommand) Run() error {
+	// Shared files are used by projects all over the tree, so all of them are searched
+	filePaths, err := generate.FindGenerateFiles([]string{c.dir}, path.Base(c.file), true)
+	if err != nil {
+		return err
+	}
+	graph, err := generate.BuildGraph(filePaths, c.parallelism.value)
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range c.paths {
+		dependents := graph.Dependents(filePath)
+		if len(dependents) == 0 {
+			fmt.Fprintf(c.out, "No generate file depends on %s.\n", filePath)
+			continue
+		}
+
+		fmt.Fprintf(c.out, "%s is used by:\n", filePath)
+		generateFile := ""
+		for _, dependent := range dependents {
+			if dependent.GenerateFile != generateFile {
+				generateFile = dependent.GenerateFile
+				fmt.Fprintf(c.out, "  %s\n", generateFile)
+			}
+			fmt.Fprintf(c.out, "    %s\n", dependent.Label)
+		}
+	}
+	return nil
+}
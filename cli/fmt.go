@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"tf-generator/generate"
+)
+
+type FmtCommand struct {
+	fs  *flag.FlagSet
+	out io.Writer
+
+	files       generateFilesFlags
+	check       bool
+	diff        bool
+	diffContext int
+	dirPaths    []string
+}
+
+// NewFmtCommand sub-command to rewrite generate files into the canonical formatting
+func NewFmtCommand() *FmtCommand {
+	c := &FmtCommand{
+		fs:  flag.NewFlagSet("fmt", flag.ContinueOnError),
+		out: os.Stdout,
+	}
+
+	c.files.register(c.fs)
+	c.fs.BoolVar(&c.check, "check", false, "only check that all generate files are formatted, without rewriting them")
+	c.fs.BoolVar(&c.diff, "diff", false, "print the changes of every unformatted file")
+	c.fs.IntVar(&c.diffContext, "diff-context", generate.DefaultDiffContext, "number of unchanged lines shown around each change")
+
+	return c
+}
+
+func (c *FmtCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *FmtCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if c.diffContext < 0 {
+		return fmt.Errorf("diff-context must not be negative, got %d", c.diffContext)
+	}
+
+	c.dirPaths = c.fs.Args()
+	return nil
+}
+
+func (c *FmtCommand) Run() error {
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+	return generate.Format(filePaths, generate.FormatOptions{
+		Check:       c.check,
+		Diff:        c.diff,
+		DiffContext: c.diffContext,
+	}, c.out)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"tf-generator/generate"
+)
+
+// generateFilesFlags selects the generate files a command operates on
+type generateFilesFlags struct {
+	file      string
+	recursive bool
+}
+
+func (f *generateFilesFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.file, "file", "tf-generator.hcl", "file used to configure file generation")
+	fs.BoolVar(&f.recursive, "recursive", false, "search the given directories recursively for generate files")
+}
+
+// filePaths finds the generate files in the given directories. Without any directories,
+// the `--file` flag is used as-is, unless `--recursive` is set to search the current directory.
+func (f *generateFilesFlags) filePaths(dirPaths []string) ([]string, error) {
+	if len(dirPaths) == 0 {
+		if !f.recursive {
+			return []string{f.file}, nil
+		}
+		dirPaths = []string{"."}
+	}
+	return generate.FindGenerateFiles(dirPaths, path.Base(f.file), f.recursive)
+}
+
+// parallelismFlag limits how many files and blocks a command evaluates at once
+type parallelismFlag struct {
+	value int
+}
+
+func (f *parallelismFlag) register(fs *flag.FlagSet) {
+	fs.IntVar(&f.value, "parallelism", 0, "maximum number of files and blocks evaluated at once, 0 for one per CPU")
+}
+
+func (f *parallelismFlag) validate() error {
+	if f.value < 0 {
+		return fmt.Errorf("parallelism must not be negative, got %d", f.value)
+	}
+	return nil
+}
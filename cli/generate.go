@@ -3,24 +3,43 @@ package cli
 import (
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"tf-generator/generate"
 )
 
 type GenerateCommand struct {
-	fs *flag.FlagSet
+	fs  *flag.FlagSet
+	out io.Writer
 
-	file  string
-	check bool
+	files       generateFilesFlags
+	check       bool
+	parallelism parallelismFlag
+	format      string
+	diffContext int
+	patchFile   string
+	lock        bool
+	incremental bool
+	dryRun      bool
+	dirPaths    []string
 }
 
 // NewGenerateCommand sub-command to generate files
 func NewGenerateCommand() *GenerateCommand {
 	c := &GenerateCommand{
-		fs: flag.NewFlagSet("generate", flag.ContinueOnError),
+		fs:  flag.NewFlagSet("generate", flag.ContinueOnError),
+		out: os.Stdout,
 	}
 
-	c.fs.StringVar(&c.file, "file", "tf-generator.hcl", "file used to configure file generation")
+	c.files.register(c.fs)
 	c.fs.BoolVar(&c.check, "check", false, "only check if file is up-to-date, do not update it")
+	c.fs.StringVar(&c.format, "format", generate.FormatText, "output format, either text or json")
+	c.fs.IntVar(&c.diffContext, "diff-context", generate.DefaultDiffContext, "number of unchanged lines shown around each difference in check mode and dry runs")
+	c.fs.StringVar(&c.patchFile, "patch-file", "", "in check mode, write all differences to this file so they can be fixed with git apply")
+	c.fs.BoolVar(&c.lock, "lock", false, "write a "+generate.LockFileName+" file with all inputs and outputs next to each generate file")
+	c.fs.BoolVar(&c.dryRun, "dry-run", false, "print the changes to every output file without writing anything")
+	c.fs.BoolVar(&c.incremental, "incremental", false, "skip generate files whose inputs did not change since the last run, implies lock")
+	c.parallelism.register(c.fs)
 
 	return c
 }
@@ -34,12 +53,45 @@ func (c *GenerateCommand) Init(args []string) error {
 		return err
 	}
 
-	if len(c.fs.Args()) != 0 {
-		return fmt.Errorf("expected no positional arguments, got %d", len(c.fs.Args()))
+	if err := c.parallelism.validate(); err != nil {
+		return err
+	}
+
+	if c.diffContext < 0 {
+		return fmt.Errorf("diff-context must not be negative, got %d", c.diffContext)
+	}
+	if c.patchFile != "" && !c.check {
+		return fmt.Errorf("patch-file can only be used with check")
+	}
+	// `git apply` rejects hunks without context unless --unidiff-zero is given
+	if c.patchFile != "" && c.diffContext == 0 {
+		return fmt.Errorf("patch-file needs a diff-context of at least 1")
 	}
+	if c.dryRun && c.check {
+		return fmt.Errorf("dry-run cannot be used with check")
+	}
+	if c.format != generate.FormatText && c.format != generate.FormatJSON {
+		return fmt.Errorf("unknown format: %s", c.format)
+	}
+
+	c.dirPaths = c.fs.Args()
 	return nil
 }
 
 func (c *GenerateCommand) Run() error {
-	return generate.Run(c.file, c.check)
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+	return generate.Run(filePaths, generate.RunOptions{
+		Check:       c.check,
+		Parallelism: c.parallelism.value,
+		Format:      c.format,
+		DiffContext: c.diffContext,
+		PatchFile:   c.patchFile,
+		Lock:        c.lock || c.incremental,
+		Incremental: c.incremental,
+		DryRun:      c.dryRun,
+		Out:         c.out,
+	})
 }
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"tf-generator/generate"
+)
+
+type GraphCommand struct {
+	fs  *flag.FlagSet
+	out io.Writer
+
+	files       generateFilesFlags
+	format      string
+	parallelism parallelismFlag
+	dirPaths    []string
+}
+
+// NewGraphCommand sub-command to print which inputs feed which locals and outputs
+func NewGraphCommand() *GraphCommand {
+	c := &GraphCommand{
+		fs:  flag.NewFlagSet("graph", flag.ContinueOnError),
+		out: os.Stdout,
+	}
+
+	c.files.register(c.fs)
+	c.fs.StringVar(&c.format, "format", generate.FormatDOT, "output format, dot or json")
+	c.parallelism.register(c.fs)
+
+	return c
+}
+
+func (c *GraphCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *GraphCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if c.format != generate.FormatDOT && c.format != generate.FormatJSON {
+		return fmt.Errorf("unknown format: %s", c.format)
+	}
+	if err := c.parallelism.validate(); err != nil {
+		return err
+	}
+
+	c.dirPaths = c.fs.Args()
+	return nil
+}
+
+func (c *GraphCommand) Run() error {
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+	graph, err := generate.BuildGraph(filePaths, c.parallelism.value)
+	if err != nil {
+		return err
+	}
+	return graph.Write(c.out, c.format)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"tf-generator/generate"
+)
+
+type InitCommand struct {
+	fs *flag.FlagSet
+
+	file    string
+	force   bool
+	root    string
+	dirPath string
+}
+
+// NewInitCommand sub-command to write a starter generate file for an existing terraform project
+func NewInitCommand() *InitCommand {
+	c := &InitCommand{
+		fs: flag.NewFlagSet("init", flag.ContinueOnError),
+	}
+
+	c.fs.StringVar(&c.file, "file", "tf-generator.hcl", "name of the generate file to create")
+	c.fs.BoolVar(&c.force, "force", false, "replace an existing generate file")
+	c.fs.StringVar(&c.root, "root", "", "last parent directory searched for shared files, defaults to the root of the git repository")
+
+	return c
+}
+
+func (c *InitCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *InitCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.dirPath = "."
+	switch c.fs.NArg() {
+	case 0:
+	case 1:
+		c.dirPath = c.fs.Arg(0)
+	default:
+		return fmt.Errorf("init takes at most one project directory, got %d", c.fs.NArg())
+	}
+	return nil
+}
+
+func (c *InitCommand) Run() error {
+	return generate.Scaffold(c.dirPath, path.Base(c.file), generate.ScaffoldOptions{Force: c.force, RootDir: c.root})
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"tf-generator/generate"
+)
+
+type RenderCommand struct {
+	fs  *flag.FlagSet
+	out io.Writer
+
+	file       string
+	block      string
+	expression string
+	dirPath    string
+}
+
+// NewRenderCommand sub-command to print the content of a single `generate{}` block or expression
+func NewRenderCommand() *RenderCommand {
+	c := &RenderCommand{
+		fs:  flag.NewFlagSet("render", flag.ContinueOnError),
+		out: os.Stdout,
+	}
+
+	c.fs.StringVar(&c.file, "file", "tf-generator.hcl", "file used to configure file generation")
+	c.fs.StringVar(&c.block, "block", "", "1-based index or output path of the generate block to render")
+	c.fs.StringVar(&c.expression, "expr", "", "expression to render instead of a block, e.g. local.shared")
+
+	return c
+}
+
+func (c *RenderCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *RenderCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if (c.block == "") == (c.expression == "") {
+		return fmt.Errorf("either block or expr must be set")
+	}
+
+	switch c.fs.NArg() {
+	case 0:
+		c.dirPath = ""
+	case 1:
+		c.dirPath = c.fs.Arg(0)
+	default:
+		return fmt.Errorf("render takes at most one directory, got %d", c.fs.NArg())
+	}
+	return nil
+}
+
+func (c *RenderCommand) Run() error {
+	filePath := c.file
+	if c.dirPath != "" {
+		filePath = path.Join(c.dirPath, path.Base(c.file))
+	}
+	return generate.Render(filePath, generate.RenderOptions{
+		Block:      c.block,
+		Expression: c.expression,
+	}, c.out)
+}
@@ -4,5 +4,13 @@ package cli
 func Run(args []string) error {
 	return RunSubcommand(args, []Command{
 		NewGenerateCommand(),
+		NewValidateCommand(),
+		NewCleanCommand(),
+		NewWatchCommand(),
+		NewGraphCommand(),
+		NewDependentsCommand(),
+		NewInitCommand(),
+		NewFmtCommand(),
+		NewRenderCommand(),
 	})
 }
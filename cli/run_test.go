@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
+	"tf-generator/generate"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,13 +28,71 @@ func TestInvalidCommands(t *testing.T) {
 			args:            []string{"unknown"},
 			expectedMessage: "unknown subcommand: unknown",
 		},
+		{
+			args:            []string{"generate", "--recursive", "unknown"},
+			expectedMessage: "lstat unknown: no such file or directory",
+		},
+		{
+			args:            []string{"generate", "--parallelism", "-1"},
+			expectedMessage: "parallelism must not be negative, got -1",
+		},
+		{
+			args:            []string{"watch", "--parallelism", "-2"},
+			expectedMessage: "parallelism must not be negative, got -2",
+		},
+		{
+			args:            []string{"graph", "--parallelism", "-2"},
+			expectedMessage: "parallelism must not be negative, got -2",
+		},
+		{
+			args:            []string{"dependents", "--parallelism", "-2"},
+			expectedMessage: "parallelism must not be negative, got -2",
+		},
+		{
+			args:            []string{"generate", "--format", "xml"},
+			expectedMessage: "unknown format: xml",
+		},
+		{
+			args:            []string{"generate", "--patch-file", "fix.patch"},
+			expectedMessage: "patch-file can only be used with check",
+		},
+		{
+			args:            []string{"generate", "--check", "--patch-file", "fix.patch", "--diff-context", "0"},
+			expectedMessage: "patch-file needs a diff-context of at least 1",
+		},
+		{
+			args:            []string{"watch", "--interval", "0s"},
+			expectedMessage: "interval must be positive, got 0s",
+		},
+		{
+			args:            []string{"graph", "--format", "svg"},
+			expectedMessage: "unknown format: svg",
+		},
+		{
+			args:            []string{"dependents"},
+			expectedMessage: "missing file to find the dependents of",
+		},
+		{
+			args:            []string{"init", "a", "b"},
+			expectedMessage: "init takes at most one project directory, got 2",
+		},
+		{
+			args:            []string{"generate", "--check", "--dry-run"},
+			expectedMessage: "dry-run cannot be used with check",
+		},
+		{
+			args:            []string{"render"},
+			expectedMessage: "either block or expr must be set",
+		},
 		{
 			args:            []string{"generate", "--unknown"},
 			expectedMessage: "flag provided but not defined: -unknown",
 		},
 		{
-			args:            []string{"generate", "unknown", "unknown"},
-			expectedMessage: "expected no positional arguments, got 2",
+			args: []string{"generate", "unknown1", "unknown2"},
+			expectedMessage: "unknown1/tf-generator.hcl: <nil>: Configuration file not found; The configuration file unknown1/tf-generator.hcl does not exist.\n\n" +
+				"unknown2/tf-generator.hcl: <nil>: Configuration file not found; The configuration file unknown2/tf-generator.hcl does not exist.\n\n" +
+				"2 failures in 2 of 2 projects",
 		},
 	} {
 		t.Run(strings.Join(fixture.args, " "), func(t *testing.T) {
@@ -38,3 +102,251 @@ func TestInvalidCommands(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDiagnostics(t *testing.T) {
+	filePath := "../fixtures/invalid/validate-errors/tf-generator.hcl"
+	expectedDiagnostics := []string{
+		"Missing load() target at " + filePath + ":5,12-35",
+		"Call to unknown function at " + filePath + ":6,7-23",
+		"Invalid function argument at " + filePath + ":7,16-26",
+		"Reference to undeclared local value at " + filePath + ":8,7-22",
+		"Cycle in locals at " + filePath + ":2,3-14",
+		"Duplicate output at " + filePath + ":16,1-9",
+	}
+
+	diagnostics := []string{}
+	for _, diag := range generate.ValidateFile(filePath) {
+		diagnostics = append(diagnostics, diag.Summary+" at "+diag.Subject.String())
+	}
+	assert.Equal(t, expectedDiagnostics, diagnostics)
+
+	// The command writes all of them at once
+	out := &bytes.Buffer{}
+	c := NewValidateCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{path.Dir(filePath)}))
+	err := c.Run()
+	assert.NotNilf(t, err, "expected error")
+	assert.Equal(t, "1 of 1 generate files are invalid", err.Error())
+	for _, diagnostic := range expectedDiagnostics {
+		summary, _, _ := strings.Cut(diagnostic, " at ")
+		assert.Contains(t, out.String(), "Error: "+summary+"\n")
+	}
+
+	out.Reset()
+	c = NewValidateCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"../fixtures/valid/custom-header"}))
+	assert.Nil(t, c.Run())
+	assert.Equal(t, "Success! 1 generate files are valid.\n", out.String())
+}
+
+func TestFmt(t *testing.T) {
+	content, err := os.ReadFile("../fixtures/invalid/unformatted/tf-generator.hcl")
+	assert.Nil(t, err)
+	golden, err := os.ReadFile("../fixtures/invalid/unformatted/tf-generator.hcl.golden")
+	assert.Nil(t, err)
+	filePath := path.Join(t.TempDir(), "tf-generator.hcl")
+	assert.Nil(t, os.WriteFile(filePath, content, 0644))
+
+	out := &bytes.Buffer{}
+	c := NewFmtCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"--diff", "--file", filePath}))
+	assert.Nil(t, c.Run())
+	formatted, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), string(formatted))
+	assert.True(t, strings.HasPrefix(out.String(), filePath+"\n"), out.String())
+	assert.True(t, strings.HasSuffix(out.String(), "@@ -1,7 +1,7 @@\n"+
+		" # Comments are kept\n"+
+		" generate {\n"+
+		"-  content = load(\"input.txt\") # and so are trailing comments\n"+
+		"+  content        = load(\"input.txt\") # and so are trailing comments\n"+
+		"   exclude-header = true\n"+
+		"-    /* block comments too */\n"+
+		"-    output = \"output.txt\"\n"+
+		"+  /* block comments too */\n"+
+		"+  output = \"output.txt\"\n"+
+		" }\n"), out.String())
+
+	// Formatting again changes nothing
+	out.Reset()
+	c = NewFmtCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"--check", "--file", filePath}))
+	assert.Nil(t, c.Run())
+	assert.Empty(t, out.String())
+}
+
+func TestDependents(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := NewDependentsCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{
+		"--dir", "../fixtures/valid/dependents",
+		"../fixtures/valid/dependents/global/common.tfvars",
+		"../fixtures/valid/dependents/unrelated/unrelated.txt",
+	}))
+	assert.Nil(t, c.Run())
+	assert.Equal(t, "../fixtures/valid/dependents/global/common.tfvars is used by:\n"+
+		"  ../fixtures/valid/dependents/chained/tf-generator.hcl\n"+
+		"    ../fixtures/valid/dependents/chained/chained.auto.tfvars\n"+
+		"  ../fixtures/valid/dependents/direct/tf-generator.hcl\n"+
+		"    ../fixtures/valid/dependents/direct/common.auto.tfvars\n"+
+		"  ../fixtures/valid/dependents/via-local/tf-generator.hcl\n"+
+		"    ../fixtures/valid/dependents/via-local/common.auto.tfvars\n"+
+		"No generate file depends on ../fixtures/valid/dependents/unrelated/unrelated.txt.\n", out.String())
+}
+
+func TestGraph(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := NewGraphCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"--format", "json", "../fixtures/valid/dependents/via-local", "../fixtures/valid/dependents/chained"}))
+	assert.Nil(t, c.Run())
+
+	graph := &generate.Graph{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), graph))
+	fixturePath, err := filepath.Abs("../fixtures/valid/dependents")
+	assert.Nil(t, err)
+	fixturePath, err = filepath.EvalSymlinks(fixturePath)
+	assert.Nil(t, err)
+	fileID := func(filePath string) string {
+		return "file:" + filepath.ToSlash(filepath.Join(fixturePath, filePath))
+	}
+	viaLocal := "../fixtures/valid/dependents/via-local/tf-generator.hcl"
+	chained := "../fixtures/valid/dependents/chained/tf-generator.hcl"
+	local := "local:" + viaLocal + "#common"
+	assert.Equal(t, []*generate.GraphNode{
+		{ID: local, Kind: generate.NodeLocal, Label: "local.common", GenerateFile: viaLocal},
+		{ID: fileID("global/common.tfvars"), Kind: generate.NodeInput, Label: "../fixtures/valid/dependents/global/common.tfvars"},
+		{ID: fileID("via-local/common.auto.tfvars"), Kind: generate.NodeOutput, Label: "../fixtures/valid/dependents/via-local/common.auto.tfvars", GenerateFile: viaLocal},
+		{ID: fileID("chained/chained.auto.tfvars"), Kind: generate.NodeOutput, Label: "../fixtures/valid/dependents/chained/chained.auto.tfvars", GenerateFile: chained},
+		{ID: fileID("direct/common.auto.tfvars"), Kind: generate.NodeInput, Label: "../fixtures/valid/dependents/direct/common.auto.tfvars"},
+	}, graph.Nodes)
+	assert.Equal(t, []*generate.GraphEdge{
+		{From: fileID("global/common.tfvars"), To: local},
+		{From: local, To: fileID("via-local/common.auto.tfvars")},
+		{From: fileID("direct/common.auto.tfvars"), To: fileID("chained/chained.auto.tfvars")},
+	}, graph.Edges)
+
+	// An output loaded by another generate file is the same node as its input
+	out.Reset()
+	c = NewGraphCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"--format", "json", "../fixtures/valid/dependents/direct", "../fixtures/valid/dependents/chained"}))
+	assert.Nil(t, c.Run())
+	graph = &generate.Graph{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), graph))
+	direct := "../fixtures/valid/dependents/direct/tf-generator.hcl"
+	assert.Equal(t, []*generate.GraphNode{
+		{ID: fileID("direct/common.auto.tfvars"), Kind: generate.NodeOutput, Label: "../fixtures/valid/dependents/direct/common.auto.tfvars", GenerateFile: direct},
+		{ID: fileID("global/common.tfvars"), Kind: generate.NodeInput, Label: "../fixtures/valid/dependents/global/common.tfvars"},
+		{ID: fileID("direct/other.txt"), Kind: generate.NodeOutput, Label: "../fixtures/valid/dependents/direct/other.txt", GenerateFile: direct},
+		{ID: fileID("chained/chained.auto.tfvars"), Kind: generate.NodeOutput, Label: "../fixtures/valid/dependents/chained/chained.auto.tfvars", GenerateFile: chained},
+	}, graph.Nodes)
+	assert.Equal(t, []*generate.GraphEdge{
+		{From: fileID("global/common.tfvars"), To: fileID("direct/common.auto.tfvars")},
+		{From: fileID("direct/common.auto.tfvars"), To: fileID("chained/chained.auto.tfvars")},
+	}, graph.Edges)
+}
+
+func TestRender(t *testing.T) {
+	for _, fixture := range []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args: []string{"--block", "1", "../fixtures/valid/custom-header"},
+			expected: "#DO NOT EDIT! This file was generated by tf-generator.\n" +
+				"# Generated by tf-generator.hcl from a.yaml\n" +
+				"# Owner: platform-team\n" +
+				"# Checksum: sha256:d4875c06b3c45e78843ac27915b105911e8675070225a996ed82410321839a90\n" +
+				"shared: true\n",
+		},
+		{
+			args: []string{"--block", "./output.md", "../fixtures/valid/custom-header"},
+			expected: "<!-- DO NOT EDIT! This file was generated by tf-generator. -->\n" +
+				"<!-- Edit a.md instead -->\n" +
+				"# Title\n",
+		},
+		{
+			args:     []string{"--expr", "local.owner", "../fixtures/valid/custom-header"},
+			expected: "platform-team",
+		},
+	} {
+		out := &bytes.Buffer{}
+		c := NewRenderCommand()
+		c.out = out
+		assert.Nil(t, c.Init(fixture.args), fixture.args)
+		assert.Nil(t, c.Run(), fixture.args)
+		assert.Equal(t, fixture.expected, out.String(), fixture.args)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	drifted := "../fixtures/invalid/multiple-drifts/first.txt"
+	missing := "../fixtures/invalid/missing-output/missing.txt"
+	driftedContent, err := os.ReadFile(drifted)
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	c := NewGenerateCommand()
+	c.out = out
+	assert.Nil(t, c.Init([]string{"--dry-run", path.Dir(drifted), path.Dir(missing)}))
+	assert.Nil(t, c.Run())
+
+	content, err := os.ReadFile(drifted)
+	assert.Nil(t, err)
+	assert.Equal(t, driftedContent, content)
+	_, err = os.Stat(missing)
+	assert.True(t, os.IsNotExist(err), "dry run created %s", missing)
+
+	// Diff paths are relative to the repository root
+	assert.Contains(t, out.String(), "  updated   "+drifted+"\n"+
+		"diff --git a/fixtures/invalid/multiple-drifts/first.txt b/fixtures/invalid/multiple-drifts/first.txt\n"+
+		"--- a/fixtures/invalid/multiple-drifts/first.txt\n"+
+		"+++ b/fixtures/invalid/multiple-drifts/first.txt\n"+
+		"@@ -1,1 +1,1 @@\n"+
+		"-outdated\n\\ No newline at end of file\n"+
+		"+first\n\\ No newline at end of file\n")
+	assert.Contains(t, out.String(), "  created   "+missing+"\n"+
+		"diff --git a/fixtures/invalid/missing-output/missing.txt b/fixtures/invalid/missing-output/missing.txt\n"+
+		"new file mode 100644\n"+
+		"--- /dev/null\n"+
+		"+++ b/fixtures/invalid/missing-output/missing.txt\n"+
+		"@@ -0,0 +1,2 @@\n"+
+		"+#DO NOT EDIT! This file was generated by tf-generator.\n"+
+		"+would be created\n")
+	assert.True(t, strings.HasSuffix(out.String(), "Dry run, no files were written.\n"))
+}
+
+func TestClean(t *testing.T) {
+	dirPath := t.TempDir()
+	writeFile := func(filePath string, content string) {
+		assert.Nil(t, os.MkdirAll(path.Dir(path.Join(dirPath, filePath)), 0755))
+		assert.Nil(t, os.WriteFile(path.Join(dirPath, filePath), []byte(content), 0644))
+	}
+	generateFile := func(output string) string {
+		return "generate {\n  content = {\n    file-name = \"\"\n    content   = \"generated\\n\"\n  }\n  output = \"" + output + "\"\n}\n"
+	}
+	generated := "#DO NOT EDIT! This file was generated by tf-generator.\ngenerated\n"
+	writeFile("tf-generator.hcl", generateFile("output.txt"))
+	writeFile("output.txt", generated)
+	writeFile("removed.txt", generated)
+	writeFile("b/tf-generator.hcl", generateFile("../shared/x.txt"))
+	writeFile("shared/x.txt", generated)
+
+	// Only the root project is loaded, the output of project b must survive anyway
+	c := NewCleanCommand()
+	assert.Nil(t, c.Init([]string{"--yes", dirPath}))
+	assert.Nil(t, c.Run())
+
+	_, err := os.Stat(path.Join(dirPath, "removed.txt"))
+	assert.True(t, os.IsNotExist(err), "orphan was not deleted")
+	for _, kept := range []string{"output.txt", "shared/x.txt"} {
+		_, err := os.Stat(path.Join(dirPath, kept))
+		assert.Nil(t, err, "%s was deleted", kept)
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"tf-generator/generate"
+)
+
+type ValidateCommand struct {
+	fs  *flag.FlagSet
+	out io.Writer // receives the diagnostics and the summary
+
+	files    generateFilesFlags
+	dirPaths []string
+}
+
+// NewValidateCommand sub-command to statically check generate files without touching any output files
+func NewValidateCommand() *ValidateCommand {
+	c := &ValidateCommand{
+		fs:  flag.NewFlagSet("validate", flag.ContinueOnError),
+		out: os.Stderr,
+	}
+
+	c.files.register(c.fs)
+
+	return c
+}
+
+func (c *ValidateCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *ValidateCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.dirPaths = c.fs.Args()
+	return nil
+}
+
+func (c *ValidateCommand) Run() error {
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+	return generate.ValidateAll(filePaths, c.out)
+}
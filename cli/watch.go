@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"tf-generator/generate"
+	"time"
+)
+
+type WatchCommand struct {
+	fs *flag.FlagSet
+
+	files       generateFilesFlags
+	interval    time.Duration
+	parallelism parallelismFlag
+	dirPaths    []string
+}
+
+// NewWatchCommand sub-command to regenerate files whenever a generate file or one of its inputs changes
+func NewWatchCommand() *WatchCommand {
+	c := &WatchCommand{
+		fs: flag.NewFlagSet("watch", flag.ContinueOnError),
+	}
+
+	c.files.register(c.fs)
+	c.fs.DurationVar(&c.interval, "interval", generate.DefaultWatchInterval, "how often files are checked for changes")
+	c.parallelism.register(c.fs)
+
+	return c
+}
+
+func (c *WatchCommand) Name() string {
+	return c.fs.Name()
+}
+
+func (c *WatchCommand) Init(args []string) error {
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if c.interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", c.interval)
+	}
+	if err := c.parallelism.validate(); err != nil {
+		return err
+	}
+
+	c.dirPaths = c.fs.Args()
+	return nil
+}
+
+func (c *WatchCommand) Run() error {
+	filePaths, err := c.files.filePaths(c.dirPaths)
+	if err != nil {
+		return err
+	}
+
+	// Stop watching on Ctrl+C
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	stop := make(chan struct{})
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	return generate.Watch(filePaths, generate.WatchOptions{
+		Parallelism: c.parallelism.value,
+		Interval:    c.interval,
+	}, stop)
+}
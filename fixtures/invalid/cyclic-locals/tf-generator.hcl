@@ -0,0 +1,14 @@
+locals {
+  a = local.b
+  b = local.c
+  c = local.a
+  d = "not part of the cycle"
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = local.a
+  }
+  output = "output.txt"
+}
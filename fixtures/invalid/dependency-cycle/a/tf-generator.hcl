@@ -0,0 +1,4 @@
+generate {
+  content = load("../b/output.txt")
+  output  = "output.txt"
+}
@@ -0,0 +1,4 @@
+generate {
+  content = load("../a/output.txt")
+  output  = "output.txt"
+}
@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "first"
+  }
+  output = "output.txt"
+}
@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "second"
+  }
+  output = "../project1/output.txt"
+}
@@ -0,0 +1,15 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "first"
+  }
+  output = "output.txt"
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = "second"
+  }
+  output = "./output.txt"
+}
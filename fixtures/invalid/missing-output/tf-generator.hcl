@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "would be created\n"
+  }
+  output = "missing.txt"
+}
@@ -0,0 +1,17 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "first"
+  }
+  exclude-header = true
+  output         = "first.txt"
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = "second"
+  }
+  exclude-header = true
+  output         = "second.txt"
+}
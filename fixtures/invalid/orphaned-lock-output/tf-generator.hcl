@@ -0,0 +1,8 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "still generated\n"
+  }
+  exclude-header = true
+  output         = "output.txt"
+}
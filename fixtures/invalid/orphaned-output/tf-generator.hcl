@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "still generated\n"
+  }
+  output = "output.txt"
+}
@@ -0,0 +1,5 @@
+generate {
+  content  = load("input.tfvars")
+  checksum = true
+  output   = "output.tfvars"
+}
@@ -0,0 +1,7 @@
+# Comments are kept
+generate {
+  content = load("input.txt") # and so are trailing comments
+  exclude-header = true
+    /* block comments too */
+    output = "output.txt"
+}
@@ -0,0 +1,19 @@
+locals {
+  a = local.b
+  b = local.c
+  c = local.a
+  d = load("does-not-exist.tfvars")
+  e = unknown-function(local.d)
+  f = combine("not a list")
+  g = local.undefined
+}
+
+generate {
+  content = local.d
+  output  = "output.txt"
+}
+
+generate {
+  content = local.e
+  output  = "./output.txt"
+}
@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "written by project b\n"
+  }
+  output = "../shared/x.txt"
+}
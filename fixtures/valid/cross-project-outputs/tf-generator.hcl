@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "root project\n"
+  }
+  output = "output.txt"
+}
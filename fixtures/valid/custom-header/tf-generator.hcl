@@ -0,0 +1,24 @@
+locals {
+  owner = "platform-team"
+}
+
+generate {
+  content = load("a.yaml")
+  header  = <<-EOT
+    Generated by ${generator.path} from%{for source in generator.sources} ${source}%{endfor}
+    Owner: ${local.owner}
+    Checksum: ${generator.hash}
+  EOT
+  output  = "output.yaml"
+}
+
+generate {
+  content = load("a.md")
+  header  = "Edit ${generator.sources[0]} instead"
+  output  = "output.md"
+}
+
+generate {
+  content = load("a.json")
+  output  = "output.json"
+}
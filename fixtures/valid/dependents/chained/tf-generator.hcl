@@ -0,0 +1,4 @@
+generate {
+  content = load("../direct/common.auto.tfvars")
+  output  = "chained.auto.tfvars"
+}
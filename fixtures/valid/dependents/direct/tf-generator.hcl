@@ -0,0 +1,12 @@
+generate {
+  content = load("../global/common.tfvars")
+  output  = "common.auto.tfvars"
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = "not using the shared file\n"
+  }
+  output = "other.txt"
+}
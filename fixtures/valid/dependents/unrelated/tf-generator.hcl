@@ -0,0 +1,7 @@
+generate {
+  content = {
+    file-name = ""
+    content   = "unrelated\n"
+  }
+  output = "unrelated.txt"
+}
@@ -0,0 +1,8 @@
+locals {
+  common = load("../global/common.tfvars")
+}
+
+generate {
+  content = local.common
+  output  = "common.auto.tfvars"
+}
@@ -0,0 +1,4 @@
+locals {
+  b = 1
+  bbb = 2
+}
@@ -0,0 +1,8 @@
+generate {
+  content = combine([
+    load("a.hcl"),
+    load("b.hcl"),
+  ])
+  format = true
+  output = "output.tf"
+}
@@ -0,0 +1,12 @@
+locals {
+  owner = load("owner.txt")
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = "generated\n"
+  }
+  header = "Owner: ${local.owner.content}"
+  output = "out.txt"
+}
@@ -0,0 +1,5 @@
+generate {
+  content        = load("input.txt")
+  exclude-header = true
+  output         = "output.txt"
+}
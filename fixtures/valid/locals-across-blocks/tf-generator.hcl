@@ -0,0 +1,20 @@
+locals {
+  a = local.c + 1
+}
+
+locals {
+  b = local.a + local.c
+}
+
+locals {
+  c = 1
+}
+
+generate {
+  content = {
+    file-name = ""
+    content   = "${local.a}+${local.b}"
+  }
+  exclude-header = true
+  output         = "output.txt"
+}
@@ -0,0 +1,4 @@
+module "mymodule" {
+  source = context.SOURCE_DIR
+  var-a  = injectvar.var-a
+}
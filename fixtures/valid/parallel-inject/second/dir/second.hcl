@@ -0,0 +1,4 @@
+module "second" {
+  source = context.SOURCE_DIR
+  var-b  = injectvar.var-b
+}
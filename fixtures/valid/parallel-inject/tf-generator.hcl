@@ -0,0 +1,87 @@
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output1.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output2.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output3.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output4.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output5.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output6.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output7.tf"
+}
+
+generate {
+  content = combine-with-inject(
+    [
+      load("first/dir/first.hcl"),
+      load("second/dir/second.hcl"),
+    ],
+    load("locals.tfvars")
+  )
+  output = "output8.tf"
+}
@@ -0,0 +1,4 @@
+generate {
+  content = load("../common.txt")
+  output  = "output.txt"
+}
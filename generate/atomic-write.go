@@ -0,0 +1,61 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces the file with the content by writing a temporary file next to it and renaming it,
+// so the file is never left half-written. The mode of an existing file is kept, new files get the mode minus
+// the umask like with os.WriteFile. Symlinks are followed so their target is replaced instead of the link.
+func writeFileAtomic(filePath string, content []byte, mode os.FileMode) error {
+	if resolvedPath, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = resolvedPath
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	info, err := os.Stat(filePath)
+	keepMode := err == nil
+	if keepMode {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := createTempFile(filePath, mode)
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // Fails once the file has been renamed
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// The umask only applies to new files, existing files keep their exact mode
+	if keepMode {
+		if err := os.Chmod(tmpPath, mode); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// createTempFile creates a new temporary file next to filePath. Unlike os.CreateTemp, the file is created with
+// the mode, so the umask is applied to it.
+func createTempFile(filePath string, mode os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		tmpPath := filepath.Join(filepath.Dir(filePath), fmt.Sprintf(".%s.tmp-%d-%d", filepath.Base(filePath), os.Getpid(), i))
+		f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
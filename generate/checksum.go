@@ -0,0 +1,43 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// checksumPattern finds the checksum in the first line of a generated file
+var checksumPattern = regexp.MustCompile(regexp.QuoteMeta(generatedFileMarker) + ` checksum: (sha256:[0-9a-f]{64})`)
+
+// TamperedError reports an output file that was edited by hand: its content no longer matches the
+// checksum in its header
+type TamperedError struct {
+	OutputFile string
+	Diff       string // unified diff from the output file to the result content
+}
+
+func (e *TamperedError) Error() string {
+	return fmt.Sprintf("the output file was edited by hand, its content does not match the checksum in its header. "+
+		"Move the changes to the sources of the file and generate it again.\n%s", e.Diff)
+}
+
+func (e *TamperedError) ExitCode() int {
+	return ExitCodeTampered
+}
+
+// addChecksum adds the checksum of everything after the first line to the generated file marker
+// in the first line of the content
+func addChecksum(content []byte) []byte {
+	firstLine, body, _ := bytes.Cut(content, []byte("\n"))
+	marker := []byte(generatedFileMarker)
+	firstLine = bytes.Replace(firstLine, marker, []byte(generatedFileMarker+" checksum: "+hashContent(body)), 1)
+	return append(append(firstLine, '\n'), body...)
+}
+
+// isTampered checks if the content of a generated file has a checksum in its first line,
+// which does not match the rest of the content anymore
+func isTampered(content []byte) bool {
+	firstLine, body, _ := bytes.Cut(content, []byte("\n"))
+	match := checksumPattern.FindSubmatch(firstLine)
+	return match != nil && string(match[1]) != hashContent(body)
+}
@@ -0,0 +1,31 @@
+package generate
+
+// Dependents returns all outputs that depend on the file, either directly, through locals, or through
+// other outputs that are loaded in turn. Outputs are returned in the order of the graph nodes.
+func (g *Graph) Dependents(filePath string) []*GraphNode {
+	dependentIDs := map[string][]string{}
+	for _, edge := range g.Edges {
+		dependentIDs[edge.From] = append(dependentIDs[edge.From], edge.To)
+	}
+
+	// Walk the graph from the file to everything depending on it
+	fileID := fileNodeID(filePath)
+	visited := map[string]bool{}
+	queue := []string{fileID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if !visited[id] {
+			visited[id] = true
+			queue = append(queue, dependentIDs[id]...)
+		}
+	}
+
+	dependents := []*GraphNode{}
+	for _, node := range g.Nodes {
+		if visited[node.ID] && node.Kind == NodeOutput && node.ID != fileID {
+			dependents = append(dependents, node)
+		}
+	}
+	return dependents
+}
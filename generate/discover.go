@@ -0,0 +1,51 @@
+package generate
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FindGenerateFiles returns the paths of all generate files named fileName in the given directories.
+// If recursive is set, every directory is walked and all nested generate files are returned as well.
+func FindGenerateFiles(dirPaths []string, fileName string, recursive bool) ([]string, error) {
+	filePaths := []string{}
+	for _, dirPath := range dirPaths {
+		if !recursive {
+			filePaths = appendUnique(filePaths, path.Join(dirPath, fileName))
+			continue
+		}
+
+		err := filepath.WalkDir(dirPath, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// Skip hidden directories like `.git` and `.terraform`
+				if filePath != dirPath && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Name() == fileName {
+				filePaths = appendUnique(filePaths, filePath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filePaths, nil
+}
+
+// appendUnique appends filePath unless it was already found, e.g. when the given directories overlap
+func appendUnique(filePaths []string, filePath string) []string {
+	filePath = path.Clean(filePath)
+	if slices.Contains(filePaths, filePath) {
+		return filePaths
+	}
+	return append(filePaths, filePath)
+}
@@ -0,0 +1,43 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+)
+
+// cachedFile is the content of a file as it was last read from disk
+type cachedFile struct {
+	hash    [sha256.Size]byte
+	content []byte
+}
+
+// fileCache keeps the last content of every file read through `load()`, keyed by its canonical path and
+// content hash, so all generate files loading an unchanged shared file use one copy of its content.
+// Files are always read from disk, since only their content tells reliably whether they changed.
+type fileCache struct {
+	mutex sync.Mutex
+	files map[string]*cachedFile
+}
+
+var sharedFileCache = &fileCache{
+	files: map[string]*cachedFile{},
+}
+
+// readFile returns the content of the file, sharing the cached copy if the content did not change
+func (c *fileCache) readFile(filePath string) ([]byte, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	key := canonicalPath(filePath)
+	hash := sha256.Sum256(content)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if cached, ok := c.files[key]; ok && cached.hash == hash {
+		return cached.content, nil
+	}
+	c.files[key] = &cachedFile{hash: hash, content: content}
+	return content, nil
+}
@@ -0,0 +1,24 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCacheSameSizeRewrite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "shared.tfvars")
+	modTime := time.Now().Truncate(time.Second)
+	cache := &fileCache{files: map[string]*cachedFile{}}
+
+	for _, content := range []string{"a = 1\n", "a = 2\n"} {
+		assert.Nil(t, os.WriteFile(filePath, []byte(content), 0644))
+		assert.Nil(t, os.Chtimes(filePath, modTime, modTime))
+		read, err := cache.readFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, content, string(read))
+	}
+}
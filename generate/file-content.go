@@ -78,7 +78,7 @@ func (fcs FileContents) Combine() *FileContent {
 }
 
 func (fc *FileContent) loadTfvars() (*tf.Tfvars, error) {
-	return tf.NewTfvars(fc.SourcePath, []byte(fc.Content))
+	return tf.CachedTfvars(fc.SourcePath, []byte(fc.Content))
 }
 
 func (fcs FileContents) MergeTfvars() (*FileContent, error) {
@@ -126,6 +126,7 @@ func (fc *FileContent) RemoveKeys(removeFc *FileContent) (*FileContent, error) {
 func (fcs FileContents) CombineWithInject(tfvarsContent *FileContent) (*FileContent, error) {
 	injections := []*inject.TfFileInjection{}
 	for _, fc := range fcs {
+		// Not cached: hclwrite files are formatted in place when they are rendered, so they cannot be shared
 		tfFile, err := tf.NewTfFile(fc.SourcePath, []byte(fc.Content))
 		if err != nil {
 			return nil, err
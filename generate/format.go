@@ -0,0 +1,70 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"io"
+	"os"
+)
+
+// FormatOptions configures the `fmt` command
+type FormatOptions struct {
+	Check       bool // only report unformatted files instead of rewriting them
+	Diff        bool // print the changes of every unformatted file
+	DiffContext int  // number of unchanged lines shown around each change
+}
+
+// Format rewrites all generate files into the canonical HCL formatting, keeping comments, and writes the
+// path and diff of every unformatted file to w. In check mode, nothing is written and an error is returned
+// if any file is not formatted.
+func Format(filePaths []string, options FormatOptions, w io.Writer) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no generate files found")
+	}
+
+	unformatted := 0
+	for _, filePath := range filePaths {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		formatted, diags := formatContent(filePath, content)
+		if diags.HasErrors() {
+			return diags
+		}
+		if bytes.Equal(content, formatted) {
+			continue
+		}
+
+		unformatted++
+		fmt.Fprintln(w, filePath)
+		if options.Diff {
+			diff, err := UnifiedDiff(filePath, content, formatted, options.DiffContext)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(w, diff)
+		}
+		if !options.Check {
+			if err := os.WriteFile(filePath, formatted, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	if options.Check && unformatted > 0 {
+		return fmt.Errorf("%d of %d generate files are not formatted, run `tf-generator fmt` to fix them", unformatted, len(filePaths))
+	}
+	return nil
+}
+
+// formatContent returns the canonical formatting of a generate file. Files with syntax errors are not
+// formatted, since hclwrite would format them unpredictably.
+func formatContent(filePath string, content []byte) ([]byte, hcl.Diagnostics) {
+	if _, diags := hclwrite.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		return nil, diags
+	}
+	return hclwrite.Format(content), nil
+}
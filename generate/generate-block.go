@@ -1,18 +1,21 @@
 package generate
 
 import (
+	"fmt"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"path"
 )
 
-const generatedFileHeader = "#DO NOT EDIT! This file was generated by tf-generator.\n"
-
 // GenerateBlock represents a single `generate{}` block
 type GenerateBlock struct {
 	Content       hcl.Expression `hcl:"content"`
 	Output        string         `hcl:"output"`
 	ExcludeHeader bool           `hcl:"exclude-header,optional"`
+	Format        bool           `hcl:"format,optional"`   // canonically format the content like `terraform fmt`
+	Header        hcl.Expression `hcl:"header,optional"`   // template of additional header lines
+	Checksum      bool           `hcl:"checksum,optional"` // add a checksum to the header to detect manual edits
+	DeclRange     hcl.Range
 }
 
 type GenerateBlocks []*GenerateBlock
@@ -20,28 +23,85 @@ type GenerateBlocks []*GenerateBlock
 // Load parses the content of the `generate{}` block and returns a GenerateResult
 func (g *GenerateBlock) Load(generateContext *GenerateContext) (*GenerateResult, hcl.Diagnostics) {
 	var fc map[string]string
-	diags := gohcl.DecodeExpression(g.Content, generateContext.EvalContext, &fc)
+	loads := Inputs{}
+	diags := gohcl.DecodeExpression(g.Content, generateContext.trackedEvalContext(loads), &fc)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 	content := fc["content"]
+	fileName := path.Join(generateContext.RootDir, g.Output)
+	if g.Format {
+		formatted, diags := formatContent(fileName, []byte(content))
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		content = string(formatted)
+	}
+	inputs := generateContext.referencedInputs(g.Content)
+	inputs.merge(loads)
 	if !g.ExcludeHeader {
-		content = generatedFileHeader + content
+		header, diags := g.loadHeader(generateContext, fileName, []byte(content), inputs, loads)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		content = header + content
+
+		// Files only read by the header are inputs as well
+		inputs.merge(generateContext.referencedInputs(g.Header))
+		inputs.merge(loads)
+	}
+	if g.Checksum {
+		if g.ExcludeHeader || headerCommentStyle(fileName) == nil {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  "Checksum without header",
+					Detail:   fmt.Sprintf("The checksum is stored in the header, but the output %s has no header.", fileName),
+					Subject:  g.DeclRange.Ptr(),
+				},
+			}
+		}
+		content = string(addChecksum([]byte(content)))
 	}
 
-	fileName := path.Join(generateContext.RootDir, g.Output)
-	return NewGenerateResult([]byte(content), fileName), nil
+	result := NewGenerateResult([]byte(content), fileName)
+	result.DeclRange = g.DeclRange
+	result.Inputs = inputs
+	result.Loads = loads
+	return result, nil
 }
 
-// LoadAll loads all `generate{}` blocks and returns all GenerateResults
+// LoadAll loads all `generate{}` blocks concurrently and returns all GenerateResults in block order
 func (l GenerateBlocks) LoadAll(generateContext *GenerateContext) (GenerateResults, hcl.Diagnostics) {
-	results := GenerateResults{}
-	for _, block := range l {
-		result, diags := block.Load(generateContext)
+	if diags := l.checkDuplicateOutputs(generateContext); diags.HasErrors() {
+		return nil, diags
+	}
+
+	results := make(GenerateResults, len(l))
+	allDiags := make([]hcl.Diagnostics, len(l))
+	limit := generateContext.limit()
+	forEachParallel(len(l), cap(limit), func(i int) {
+		limit.run(func() {
+			results[i], allDiags[i] = l[i].Load(generateContext)
+		})
+	})
+
+	// Report the first failing block so errors are the same on every run
+	for _, diags := range allDiags {
 		if diags.HasErrors() {
 			return nil, diags
 		}
-		results = append(results, result)
 	}
 	return results, nil
 }
+
+// checkDuplicateOutputs reports blocks writing to the same output file before any of them is evaluated
+func (l GenerateBlocks) checkDuplicateOutputs(generateContext *GenerateContext) hcl.Diagnostics {
+	outputs := GenerateResults{}
+	for _, block := range l {
+		output := NewGenerateResult(nil, path.Join(generateContext.RootDir, block.Output))
+		output.DeclRange = block.DeclRange
+		outputs = append(outputs, output)
+	}
+	return outputs.CheckDuplicateOutputs()
+}
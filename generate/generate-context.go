@@ -5,10 +5,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
-	"os"
 	"path"
-	"slices"
-	"sort"
 )
 
 // GenerateContext implements all HCL functions available in generate files, and tracks
@@ -16,7 +13,11 @@ import (
 type GenerateContext struct {
 	RootDir     string
 	EvalContext *hcl.EvalContext
+	Parallelism int     // maximum number of `generate{}` blocks evaluated at once, 0 for one per CPU
+	limiter     limiter // shared with other generate files of the same run, overrides Parallelism
 	locals      map[string]cty.Value
+	localInputs map[string]Inputs // files each local depends on, including those of referenced locals
+	localLoads  map[string]Inputs // files each local reads itself
 }
 
 func NewGenerateContext(rootDir string) *GenerateContext {
@@ -24,7 +25,7 @@ func NewGenerateContext(rootDir string) *GenerateContext {
 		RootDir: rootDir,
 		EvalContext: &hcl.EvalContext{
 			Functions: map[string]function.Function{
-				"load":                loadFunc(rootDir),
+				"load":                loadFunc(rootDir, nil),
 				"combine":             combineFunc(),
 				"merge-tfvars":        mergeTfvarsFunc(),
 				"remove-tfvar-keys":   removeTfvarKeys(),
@@ -35,10 +36,20 @@ func NewGenerateContext(rootDir string) *GenerateContext {
 				"local": cty.ObjectVal(map[string]cty.Value{}),
 			},
 		},
-		locals: map[string]cty.Value{},
+		locals:      map[string]cty.Value{},
+		localInputs: map[string]Inputs{},
+		localLoads:  map[string]Inputs{},
 	}
 }
 
+// limit returns the limiter of the generate context, which is created from Parallelism unless it is shared
+func (gc *GenerateContext) limit() limiter {
+	if gc.limiter == nil {
+		gc.limiter = newLimiter(gc.Parallelism)
+	}
+	return gc.limiter
+}
+
 func (gc *GenerateContext) addLocal(name string, val cty.Value) bool {
 	if _, ok := gc.locals[name]; ok {
 		return false
@@ -48,58 +59,24 @@ func (gc *GenerateContext) addLocal(name string, val cty.Value) bool {
 	return true
 }
 
-func nestedRefs(varReferences map[string][]string, varName string) []string {
-	nested := []string{}
-	if _, ok := varReferences[varName]; ok {
-		for _, refName := range varReferences[varName] {
-			nested = append(nested, refName)
-			if _, ok := varReferences[refName]; ok {
-				nested = append(nested, nestedRefs(varReferences, refName)...)
-			}
-		}
-	}
-	return nested
-}
-
-// AddLocals loads the values of all locals defined in the body
-func (gc *GenerateContext) AddLocals(body hcl.Body) hcl.Diagnostics {
-	attributesMap, diags := body.JustAttributes()
+// AddLocals loads the values of all given locals, in order of their references
+func (gc *GenerateContext) AddLocals(attributes []*hcl.Attribute) hcl.Diagnostics {
+	// Ensure locals referencing other locals are loaded last
+	attributes, diags := newLocalsGraph(attributes).sorted()
 	if diags.HasErrors() {
 		return diags
 	}
-	attributes := []*hcl.Attribute{}
-	for _, v := range attributesMap {
-		attributes = append(attributes, v)
-	}
-
-	// Ensure locals referencing other locals are loaded last
-	varReferences := map[string][]string{}
-	for _, attribute := range attributes { // Find immediate references to other locals
-		for _, variable := range attribute.Expr.Variables() {
-			if variable.RootName() == "local" && len(variable) == 2 {
-				if ref, ok := variable[1].(hcl.TraverseAttr); ok {
-					varReferences[attribute.Name] = append(varReferences[attribute.Name], ref.Name)
-				}
-			}
-		}
-	}
-	for varName, refNames := range varReferences { // Find nested references to other locals
-		for _, refName := range nestedRefs(varReferences, varName) {
-			if !slices.Contains(refNames, refName) {
-				varReferences[varName] = append(varReferences[varName], refName)
-			}
-		}
-	}
-	sort.Slice(attributes, func(i, j int) bool {
-		return !slices.Contains(varReferences[attributes[j].Name], attributes[i].Name)
-	})
-	slices.Reverse(attributes)
 
 	for _, attr := range attributes {
-		val, diags := attr.Expr.Value(gc.EvalContext)
+		loads := Inputs{}
+		val, diags := attr.Expr.Value(gc.trackedEvalContext(loads))
 		if diags.HasErrors() {
 			return diags
 		}
+		inputs := gc.referencedInputs(attr.Expr)
+		inputs.merge(loads)
+		gc.localInputs[attr.Name] = inputs
+		gc.localLoads[attr.Name] = loads
 
 		if !gc.addLocal(attr.Name, val) {
 			return hcl.Diagnostics{
@@ -114,7 +91,8 @@ func (gc *GenerateContext) AddLocals(body hcl.Body) hcl.Diagnostics {
 	return nil
 }
 
-func loadFunc(rootDir string) function.Function {
+// loadFunc reads a file relative to rootDir, and records it in inputs unless inputs is nil
+func loadFunc(rootDir string, inputs Inputs) function.Function {
 	return function.New(&function.Spec{
 		Params: []function.Parameter{
 			{Name: "source", Type: cty.String},
@@ -123,10 +101,13 @@ func loadFunc(rootDir string) function.Function {
 		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
 			sourcePath := args[0].AsString()
 			filePath := path.Join(rootDir, sourcePath)
-			content, err := os.ReadFile(filePath)
+			content, err := sharedFileCache.readFile(filePath)
 			if err != nil {
 				return cty.NilVal, err
 			}
+			if inputs != nil {
+				inputs.add(path.Clean(sourcePath), content)
+			}
 			fileContent := NewFileContent(sourcePath, string(content))
 			return fileContent.ToCty(), nil
 		},
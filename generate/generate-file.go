@@ -1,11 +1,10 @@
 package generate
 
 import (
-	"bytes"
+	"fmt"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
-	"github.com/hashicorp/hcl/v2/hclsimple"
-	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"os"
 	"path"
 )
@@ -26,11 +25,39 @@ func LoadGenerateFile(filePath string) (*GenerateFile, error) {
 		GenerateContext: NewGenerateContext(path.Dir(filePath)),
 	}
 
-	ctx := g.GenerateContext.EvalContext
-	if err := hclsimple.DecodeFile(filePath, ctx, g); err != nil {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  "Configuration file not found",
+					Detail:   fmt.Sprintf("The configuration file %s does not exist.", filePath),
+				},
+			}
+		}
 		return nil, err
 	}
 
+	file, diags := hclsyntax.ParseConfig(src, filePath, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	ctx := g.GenerateContext.EvalContext
+	if diags := gohcl.DecodeBody(file.Body, ctx, g); diags.HasErrors() {
+		return nil, diags
+	}
+
+	// Keep track of where each `generate{}` block is defined for diagnostics
+	generateBlockIndex := 0
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == "generate" {
+			g.GenerateBlocks[generateBlockIndex].DeclRange = block.DefRange()
+			generateBlockIndex++
+		}
+	}
+
 	return g, nil
 }
 
@@ -38,29 +65,14 @@ func LoadGenerateFile(filePath string) (*GenerateFile, error) {
 // the files to be checked/written.
 func (g *GenerateFile) LoadAll() (GenerateResults, hcl.Diagnostics) {
 	// Parse locals if needed
-	if diags := g.Locals.LoadAll(g.GenerateContext); diags.HasErrors() {
+	var diags hcl.Diagnostics
+	g.GenerateContext.limit().run(func() {
+		diags = g.Locals.LoadAll(g.GenerateContext)
+	})
+	if diags.HasErrors() {
 		return nil, diags
 	}
 
 	// Determine generate results
 	return g.GenerateBlocks.LoadAll(g.GenerateContext)
 }
-
-// Save writes the GenerateFile to an `.hcl` file.
-func (g *GenerateFile) Save() error {
-	// Render file
-	f := hclwrite.NewEmptyFile()
-	gohcl.EncodeIntoBody(g, f.Body())
-	fileContent := f.Bytes()
-
-	// Remove the leading newline
-	if bytes.HasPrefix(fileContent, []byte("\n")) {
-		fileContent = fileContent[1:]
-	}
-
-	// Add empty newline between import-tfvars blocks
-	fileContent = bytes.ReplaceAll(fileContent, []byte("}\nimport-tfvars {"), []byte("}\n\nimport-tfvars {"))
-
-	// Write file
-	return os.WriteFile(g.FilePath, fileContent, 0644)
-}
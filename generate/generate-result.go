@@ -1,19 +1,49 @@
 package generate
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/hashicorp/hcl/v2"
 	"os"
+	"path"
+	"path/filepath"
 )
 
 // GenerateResult tracks the result of a `generate{}` block and performs the resulting actions
 type GenerateResult struct {
 	Content    []byte
 	OutputFile string
+	DeclRange  hcl.Range // range of the `generate{}` block that produced the result
+	Inputs     Inputs    // files read through `load()` to produce the content, including those of referenced locals
+	Loads      Inputs    // files read through `load()` by the block itself
 }
 
 type GenerateResults []*GenerateResult
 
+// ResultStatus describes what happened to the output file of a GenerateResult
+type ResultStatus string
+
+const (
+	StatusUnchanged ResultStatus = "unchanged" // the output file already matches the content
+	StatusUpdated   ResultStatus = "updated"   // the output file was replaced with the content
+	StatusCreated   ResultStatus = "created"   // the output file did not exist and was written
+	StatusDrifted   ResultStatus = "drifted"   // the output file does not match the content in check mode
+	StatusTampered  ResultStatus = "tampered"  // the output file was edited by hand since it was generated, in check mode
+	StatusMissing   ResultStatus = "missing"   // the output file does not exist yet and would be created in check mode
+	StatusOrphaned  ResultStatus = "orphaned"  // a generated file that no block produces anymore in check mode
+	StatusSkipped   ResultStatus = "skipped"   // the inputs did not change since the last run, nothing was evaluated
+	StatusError     ResultStatus = "error"     // the result could not be evaluated, checked or written
+)
+
+// Exit codes of failed runs, so CI can tell the different kinds of failures apart
+const (
+	ExitCodeError    = 1 // evaluation or I/O errors
+	ExitCodeDrifted  = 2 // output files that do not match their content
+	ExitCodeMissing  = 3 // output files that do not exist yet
+	ExitCodeOrphaned = 4 // generated files that no block produces anymore
+	ExitCodeTampered = 5 // generated files that were edited by hand
+)
+
 func NewGenerateResult(content []byte, name string) *GenerateResult {
 	return &GenerateResult{
 		Content:    content,
@@ -21,28 +51,140 @@ func NewGenerateResult(content []byte, name string) *GenerateResult {
 	}
 }
 
-// Check checks if the output file matches the result content
-func (r *GenerateResult) Check() error {
+// CheckDuplicateOutputs reports results that write to the same output file, including paths that
+// only differ in their notation (e.g. `./a.tf` and `dir/../a.tf`) or that resolve through symlinks
+func (rs GenerateResults) CheckDuplicateOutputs() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	resultsByOutput := map[string]*GenerateResult{}
+	for _, r := range rs {
+		outputFile := canonicalPath(r.OutputFile)
+		if existing, ok := resultsByOutput[outputFile]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate output",
+				Detail: fmt.Sprintf(
+					"The output %s is also generated by the block at %s. Only one `generate{}` block may write to a file.",
+					r.OutputFile,
+					existing.DeclRange,
+				),
+				Subject: r.DeclRange.Ptr(),
+			})
+			continue
+		}
+		resultsByOutput[outputFile] = r
+	}
+	return diags
+}
+
+// canonicalPath returns an absolute path with all symlinks of its directory resolved, if possible
+func canonicalPath(filePath string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return path.Clean(filePath)
+	}
+	dirPath, err := filepath.EvalSymlinks(filepath.Dir(absPath))
+	if err != nil {
+		return absPath
+	}
+	return filepath.Join(dirPath, filepath.Base(absPath))
+}
+
+// DriftError reports an output file that does not match the result content
+type DriftError struct {
+	OutputFile string
+	Diff       string // unified diff from the output file to the result content
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("the new tfvars file does not match the existing file.\n%s", e.Diff)
+}
+
+func (e *DriftError) ExitCode() int {
+	return ExitCodeDrifted
+}
+
+// MissingError reports an output file that does not exist yet
+type MissingError struct {
+	OutputFile string
+	Diff       string // unified diff creating the output file with the full result content
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("the output file does not exist yet and would be created.\n%s", e.Diff)
+}
+
+func (e *MissingError) ExitCode() int {
+	return ExitCodeMissing
+}
+
+// Check checks if the output file matches the result content. Differences are reported
+// as a DriftError with a unified diff showing contextLines unchanged lines around each change, or as a
+// TamperedError if the checksum in the header shows that the output file was edited by hand.
+// A missing output file is reported as a MissingError with the full content.
+func (r *GenerateResult) Check(contextLines int) (ResultStatus, error) {
 	// Read the file into a byte slice
 	actualFileContent, err := os.ReadFile(r.OutputFile)
+	if os.IsNotExist(err) {
+		diff, err := UnifiedDiff(r.OutputFile, nil, r.Content, contextLines)
+		if err != nil {
+			return StatusError, err
+		}
+		return StatusMissing, &MissingError{OutputFile: r.OutputFile, Diff: diff}
+	}
 	if err != nil {
-		return err
+		return StatusError, err
+	}
+	if bytes.Equal(actualFileContent, r.Content) {
+		return StatusUnchanged, nil
 	}
 
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(string(actualFileContent), string(r.Content), true)
+	diff, err := UnifiedDiff(r.OutputFile, actualFileContent, r.Content, contextLines)
+	if err != nil {
+		return StatusError, err
+	}
+	if isTampered(actualFileContent) {
+		return StatusTampered, &TamperedError{OutputFile: r.OutputFile, Diff: diff}
+	}
+	return StatusDrifted, &DriftError{OutputFile: r.OutputFile, Diff: diff}
+}
 
-	if !(len(diffs) == 1 && diffs[0].Type == diffmatchpatch.DiffEqual) {
-		return fmt.Errorf(
-			"the new tfvars file does not match the existing file.\n%s\n%s",
-			dmp.DiffToDelta(diffs),
-			dmp.DiffPrettyText(diffs),
-		)
+// DryRun returns what Save would do and the diff from the output file to the result content, without
+// writing anything. The diff of a new output file contains its full content.
+func (r *GenerateResult) DryRun(contextLines int) (ResultStatus, string, error) {
+	actualFileContent, err := os.ReadFile(r.OutputFile)
+	if os.IsNotExist(err) {
+		diff, err := UnifiedDiff(r.OutputFile, nil, r.Content, contextLines)
+		if err != nil {
+			return StatusError, "", err
+		}
+		return StatusCreated, diff, nil
+	}
+	if err != nil {
+		return StatusError, "", err
+	}
+	if bytes.Equal(actualFileContent, r.Content) {
+		return StatusUnchanged, "", nil
 	}
-	return nil
+	diff, err := UnifiedDiff(r.OutputFile, actualFileContent, r.Content, contextLines)
+	if err != nil {
+		return StatusError, "", err
+	}
+	return StatusUpdated, diff, nil
 }
 
-// Save replaces the output file with the result content
-func (r *GenerateResult) Save() error {
-	return os.WriteFile(r.OutputFile, r.Content, 0644)
+// Save replaces the output file with the result content. The file is written atomically and keeps
+// its mode, and is not touched at all if it already matches the content.
+func (r *GenerateResult) Save() (ResultStatus, error) {
+	status := StatusUpdated
+	actualFileContent, err := os.ReadFile(r.OutputFile)
+	if os.IsNotExist(err) {
+		status = StatusCreated
+	} else if err == nil && bytes.Equal(actualFileContent, r.Content) {
+		return StatusUnchanged, nil
+	}
+
+	if err := writeFileAtomic(r.OutputFile, r.Content, 0644); err != nil {
+		return StatusError, err
+	}
+	return status, nil
 }
@@ -0,0 +1,159 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FormatDOT renders the dependency graph for Graphviz
+const FormatDOT = "dot"
+
+// Kinds of nodes in the dependency graph
+const (
+	NodeInput  = "input"  // a file read through `load()`
+	NodeLocal  = "local"  // a local of a generate file
+	NodeOutput = "output" // a file written by a `generate{}` block, which may be read by other generate files
+)
+
+// Graph shows which files feed which locals and generated outputs. Every file is a single node, whether
+// it is read by several generate files or written by one and read by others, so all outputs affected by
+// a change can be followed from it.
+type Graph struct {
+	Nodes []*GraphNode          `json:"nodes"`
+	Edges []*GraphEdge          `json:"edges"`
+	nodes map[string]*GraphNode // all nodes added so far by ID
+	edges map[GraphEdge]bool    // all edges added so far
+}
+
+// GraphNode a single input, local or output
+type GraphNode struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	Label        string `json:"label"`
+	GenerateFile string `json:"generate_file,omitempty"` // the generate file defining a local or output
+}
+
+// GraphEdge the To node depends on the From node
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BuildGraph evaluates all generate files and returns the dependency graph of their inputs, locals and outputs
+func BuildGraph(filePaths []string, parallelism int) (*Graph, error) {
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("no generate files found")
+	}
+
+	generateFiles := make([]*GenerateFile, len(filePaths))
+	results := make([]GenerateResults, len(filePaths))
+	errs := make([]error, len(filePaths))
+	limit := newLimiter(parallelism)
+	forEachParallel(len(filePaths), cap(limit), func(i int) {
+		generateFiles[i], results[i], errs[i] = evaluateGenerateFile(filePaths[i], limit)
+	})
+
+	g := &Graph{Nodes: []*GraphNode{}, Edges: []*GraphEdge{}, nodes: map[string]*GraphNode{}, edges: map[GraphEdge]bool{}}
+	for i, generateFile := range generateFiles {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", filePaths[i], errs[i])
+		}
+		g.addGenerateFile(generateFile, results[i])
+	}
+	return g, nil
+}
+
+// addGenerateFile adds the locals and outputs of an evaluated generate file with their dependencies
+func (g *Graph) addGenerateFile(generateFile *GenerateFile, results GenerateResults) {
+	loadsByLocal := generateFile.GenerateContext.localLoads
+	attributes, _ := generateFile.Locals.attributes()
+	for _, attr := range attributes {
+		localID := g.addNode(localNodeID(generateFile.FilePath, attr.Name), NodeLocal, "local."+attr.Name, generateFile.FilePath)
+		g.addDependencies(localID, generateFile.FilePath, localRefs(attr.Expr), loadsByLocal[attr.Name])
+	}
+
+	for i, result := range results {
+		outputID := g.addNode(fileNodeID(result.OutputFile), NodeOutput, result.OutputFile, generateFile.FilePath)
+		g.addDependencies(outputID, generateFile.FilePath, localRefs(generateFile.GenerateBlocks[i].Content), result.Loads)
+	}
+}
+
+// addDependencies adds edges from the referenced locals and the loaded files to the node
+func (g *Graph) addDependencies(nodeID string, generateFile string, localNames []string, loads Inputs) {
+	for _, localName := range localNames {
+		g.addEdge(localNodeID(generateFile, localName), nodeID)
+	}
+	for _, sourcePath := range loads.Paths() {
+		inputPath := path.Join(path.Dir(generateFile), sourcePath)
+		g.addEdge(g.addNode(fileNodeID(inputPath), NodeInput, inputPath, ""), nodeID)
+	}
+}
+
+// addNode adds a node unless it already exists, and returns its ID. A file added as an input
+// becomes an output once a generate file writes it.
+func (g *Graph) addNode(id string, kind string, label string, generateFile string) string {
+	node, ok := g.nodes[id]
+	if !ok {
+		node = &GraphNode{ID: id, Kind: kind, Label: label, GenerateFile: generateFile}
+		g.nodes[id] = node
+		g.Nodes = append(g.Nodes, node)
+	} else if kind == NodeOutput {
+		node.Kind, node.Label, node.GenerateFile = kind, label, generateFile
+	}
+	return id
+}
+
+// addEdge adds an edge unless it already exists
+func (g *Graph) addEdge(from string, to string) {
+	edge := GraphEdge{From: from, To: to}
+	if !g.edges[edge] {
+		g.edges[edge] = true
+		g.Edges = append(g.Edges, &edge)
+	}
+}
+
+// fileNodeID files are identified by their canonical path, so every file is a single node
+func fileNodeID(filePath string) string {
+	return "file:" + filepath.ToSlash(canonicalPath(filePath))
+}
+
+// localNodeID locals are only visible in their own generate file, so their IDs include it
+func localNodeID(generateFile string, localName string) string {
+	return NodeLocal + ":" + generateFile + "#" + localName
+}
+
+// Write renders the graph in the given format, FormatDOT or FormatJSON
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return g.writeDOT(w)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(g)
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// writeDOT renders the graph in the Graphviz DOT language, with inputs on the left and outputs on the right
+func (g *Graph) writeDOT(w io.Writer) error {
+	shapes := map[string]string{NodeInput: "note", NodeLocal: "ellipse", NodeOutput: "box"}
+
+	sb := strings.Builder{}
+	sb.WriteString("digraph tf_generator {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&sb, "  %q [label=%q, shape=%s];\n", node.ID, node.Label, shapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&sb, "  %q -> %q;\n", edge.From, edge.To)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,130 @@
+package generate
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const generatedFileHeader = "#" + generatedFileMarker + "\n"
+
+// commentStyle how the lines of the header are commented out in an output file
+type commentStyle struct {
+	prefix string
+	suffix string
+}
+
+var (
+	hashComment = &commentStyle{prefix: "# "}
+	htmlComment = &commentStyle{prefix: "<!-- ", suffix: " -->"}
+)
+
+// headerCommentStyle returns the comment style of the output file based on its extension, or nil
+// for formats without comments like JSON
+func headerCommentStyle(outputFile string) *commentStyle {
+	switch strings.ToLower(path.Ext(outputFile)) {
+	case ".json":
+		return nil
+	case ".md", ".markdown", ".html", ".xml":
+		return htmlComment
+	}
+	return hashComment
+}
+
+// header returns the header of the output file: the generated file marker on the first line, followed
+// by the lines of the header template
+func (c *commentStyle) header(lines string) string {
+	sb := strings.Builder{}
+	if c == hashComment {
+		sb.WriteString(generatedFileHeader) // Kept without a space, so existing outputs do not change
+	} else {
+		sb.WriteString(c.prefix + generatedFileMarker + c.suffix + "\n")
+	}
+	if lines != "" {
+		for _, line := range strings.Split(strings.TrimRight(lines, "\n"), "\n") {
+			sb.WriteString(strings.TrimRight(c.prefix+line, " ") + c.suffix + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// loadHeader evaluates the header template of the block for the content of its output file. The template
+// may reference `generator.path`, `generator.sources` and `generator.hash`, all relative to the output file.
+// Files loaded by the template are recorded in loads.
+func (g *GenerateBlock) loadHeader(generateContext *GenerateContext, outputFile string, content []byte, inputs Inputs, loads Inputs) (string, hcl.Diagnostics) {
+	style := headerCommentStyle(outputFile)
+	hasTemplate := g.Header != nil && !isNullExpression(g.Header)
+	if style == nil {
+		if hasTemplate {
+			return "", hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported header",
+					Detail:   fmt.Sprintf("The output %s cannot contain comments, so it cannot have a header. Remove the header attribute.", outputFile),
+					Subject:  g.Header.Range().Ptr(),
+				},
+			}
+		}
+		return "", nil
+	}
+	if !hasTemplate {
+		return style.header(""), nil
+	}
+
+	outputDir := path.Dir(outputFile)
+	sources := []cty.Value{}
+	for _, sourcePath := range inputs.Paths() {
+		sources = append(sources, cty.StringVal(relativePath(outputDir, path.Join(generateContext.RootDir, sourcePath))))
+	}
+	generator := map[string]cty.Value{
+		"path":    cty.StringVal(relativePath(outputDir, g.DeclRange.Filename)),
+		"sources": cty.ListValEmpty(cty.String),
+		"hash":    cty.StringVal(hashContent(content)),
+	}
+	if len(sources) > 0 {
+		generator["sources"] = cty.ListVal(sources)
+	}
+
+	ctx := generateContext.trackedEvalContext(loads)
+	ctx.Variables = map[string]cty.Value{
+		"generator": cty.ObjectVal(generator),
+	}
+	val, diags := g.Header.Value(ctx)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil || val.IsNull() || !val.IsKnown() {
+		return "", hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid header",
+				Detail:   "The header must be a string.",
+				Subject:  g.Header.Range().Ptr(),
+			},
+		}
+	}
+	return style.header(val.AsString()), nil
+}
+
+// isNullExpression checks for the placeholder expression of an omitted optional attribute
+func isNullExpression(expr hcl.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return false
+	}
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
+}
+
+// relativePath returns the slash-separated path of filePath relative to dirPath
+func relativePath(dirPath string, filePath string) string {
+	relPath, err := filepath.Rel(dirPath, filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(relPath)
+}
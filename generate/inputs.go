@@ -0,0 +1,58 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty/function"
+	"sort"
+)
+
+// Inputs maps the paths of all files read through `load()`, relative to the generate file,
+// to the hash of the content that was read
+type Inputs map[string]string
+
+// hashContent returns a content hash in the format used by lock files
+func hashContent(content []byte) string {
+	hash := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func (i Inputs) add(sourcePath string, content []byte) {
+	i[sourcePath] = hashContent(content)
+}
+
+func (i Inputs) merge(other Inputs) {
+	for sourcePath, hash := range other {
+		i[sourcePath] = hash
+	}
+}
+
+// Paths returns the paths of all inputs in alphabetical order
+func (i Inputs) Paths() []string {
+	paths := make([]string, 0, len(i))
+	for sourcePath := range i {
+		paths = append(paths, sourcePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// trackedEvalContext returns a child of the generate context which records every file read through
+// `load()` in loads
+func (gc *GenerateContext) trackedEvalContext(loads Inputs) *hcl.EvalContext {
+	ctx := gc.EvalContext.NewChild()
+	ctx.Functions = map[string]function.Function{
+		"load": loadFunc(gc.RootDir, loads),
+	}
+	return ctx
+}
+
+// referencedInputs returns the inputs of all locals referenced by expr, since its value depends on them
+func (gc *GenerateContext) referencedInputs(expr hcl.Expression) Inputs {
+	inputs := Inputs{}
+	for _, refName := range localRefs(expr) {
+		inputs.merge(gc.localInputs[refName])
+	}
+	return inputs
+}
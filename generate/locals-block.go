@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"fmt"
 	"github.com/hashicorp/hcl/v2"
 )
 
@@ -11,12 +12,37 @@ type LocalsBlock struct {
 
 type LocalsBlocks []*LocalsBlock
 
-// LoadAll parses the contents of all locals and stores them as locals for later usage
+// LoadAll parses the contents of all locals and stores them as locals for later usage.
+// All `locals{}` blocks share one namespace, so locals may reference locals of any other block.
 func (l LocalsBlocks) LoadAll(generateContext *GenerateContext) hcl.Diagnostics {
+	attributes, diags := l.attributes()
+	if diags.HasErrors() {
+		return diags
+	}
+	return generateContext.AddLocals(attributes)
+}
+
+// attributes returns the locals of all blocks in the order they are defined in
+func (l LocalsBlocks) attributes() ([]*hcl.Attribute, hcl.Diagnostics) {
+	diags := hcl.Diagnostics{}
+	attributes := []*hcl.Attribute{}
+	definedLocals := map[string]*hcl.Attribute{}
 	for _, block := range l {
-		if diags := generateContext.AddLocals(block.Values); diags.HasErrors() {
-			return diags
+		attributesMap, attrDiags := block.Values.JustAttributes()
+		diags = append(diags, attrDiags...)
+		for _, attr := range sortedAttributes(attributesMap) {
+			if existing, ok := definedLocals[attr.Name]; ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("local %q already defined", attr.Name),
+					Detail:   fmt.Sprintf("The local %q was already defined at %s.", attr.Name, existing.Range),
+					Subject:  &attr.Range,
+				})
+				continue
+			}
+			definedLocals[attr.Name] = attr
+			attributes = append(attributes, attr)
 		}
 	}
-	return nil
+	return attributes, diags
 }
@@ -0,0 +1,151 @@
+package generate
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"sort"
+	"strings"
+)
+
+// localsGraph tracks which locals reference which other locals
+type localsGraph struct {
+	names      []string // in definition order for deterministic results
+	attributes map[string]*hcl.Attribute
+	references map[string][]string // references to other locals in the graph
+}
+
+func newLocalsGraph(attributes []*hcl.Attribute) *localsGraph {
+	g := &localsGraph{
+		names:      []string{},
+		attributes: map[string]*hcl.Attribute{},
+		references: map[string][]string{},
+	}
+	for _, attribute := range attributes {
+		g.names = append(g.names, attribute.Name)
+		g.attributes[attribute.Name] = attribute
+	}
+
+	for _, attribute := range attributes {
+		for _, refName := range localRefs(attribute.Expr) {
+			if _, ok := g.attributes[refName]; ok {
+				g.references[attribute.Name] = append(g.references[attribute.Name], refName)
+			}
+		}
+	}
+	return g
+}
+
+// localRefs returns the names of all locals referenced by the expression, e.g. `a` for `local.a`
+func localRefs(expr hcl.Expression) []string {
+	refs := []string{}
+	for _, variable := range expr.Variables() {
+		if refName, ok := localRefName(variable); ok {
+			refs = append(refs, refName)
+		}
+	}
+	return refs
+}
+
+// localRefName returns the name of the local referenced by a traversal like `local.a`
+func localRefName(variable hcl.Traversal) (string, bool) {
+	if variable.RootName() != "local" || len(variable) < 2 {
+		return "", false
+	}
+	ref, ok := variable[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+	return ref.Name, true
+}
+
+// sorted returns the attributes ordered so every local comes after all locals it references,
+// or the diagnostics of all cycles that prevent such an order
+func (g *localsGraph) sorted() ([]*hcl.Attribute, hcl.Diagnostics) {
+	if diags := g.cycles(); diags.HasErrors() {
+		return nil, diags
+	}
+
+	sorted := []*hcl.Attribute{}
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		visited[name] = true
+		for _, refName := range g.references[name] {
+			if !visited[refName] {
+				visit(refName)
+			}
+		}
+		sorted = append(sorted, g.attributes[name])
+	}
+
+	for _, name := range g.names {
+		if !visited[name] {
+			visit(name)
+		}
+	}
+	return sorted, nil
+}
+
+// cycles returns a diagnostic for every reference cycle between locals, naming the full cycle path
+func (g *localsGraph) cycles() hcl.Diagnostics {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	diags := hcl.Diagnostics{}
+
+	var visit func(name string, stack []string)
+	visit = func(name string, stack []string) {
+		states[name] = visiting
+		stack = append(stack, name)
+		for _, refName := range g.references[name] {
+			switch states[refName] {
+			case unvisited:
+				visit(refName, stack)
+			case visiting:
+				diags = append(diags, g.cycleDiagnostic(stack, refName))
+			}
+		}
+		states[name] = visited
+	}
+
+	for _, name := range g.names {
+		if states[name] == unvisited {
+			visit(name, []string{})
+		}
+	}
+	return diags
+}
+
+// cycleDiagnostic reports the cycle at the end of stack that returns to name
+func (g *localsGraph) cycleDiagnostic(stack []string, name string) *hcl.Diagnostic {
+	path := []string{}
+	for i := len(stack) - 1; i >= 0; i-- {
+		path = append([]string{"local." + stack[i]}, path...)
+		if stack[i] == name {
+			break
+		}
+	}
+	path = append(path, "local."+name)
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Cycle in locals",
+		Detail:   fmt.Sprintf("The locals reference each other in a cycle: %s.", strings.Join(path, " -> ")),
+		Subject:  g.attributes[name].Range.Ptr(),
+	}
+}
+
+// sortedAttributes returns the attributes in the order they are defined in
+func sortedAttributes(attributesMap hcl.Attributes) []*hcl.Attribute {
+	attributes := []*hcl.Attribute{}
+	for _, attr := range attributesMap {
+		attributes = append(attributes, attr)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Range.Start.Byte < attributes[j].Range.Start.Byte
+	})
+	return attributes
+}
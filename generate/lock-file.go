@@ -0,0 +1,173 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// LockFileName name of the lock file written next to each generate file
+const LockFileName = ".tf-generator.lock.json"
+
+const lockFileVersion = 1
+
+// LockFile records the inputs and outputs of the last run of a generate file.
+// All paths are slash-separated and relative to the directory of the generate file.
+type LockFile struct {
+	Version      int          `json:"version"`
+	GenerateFile LockEntry    `json:"generate_file"`
+	Inputs       []LockEntry  `json:"inputs"`
+	Outputs      []LockOutput `json:"outputs"`
+}
+
+// LockEntry a file and the hash of its content
+type LockEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// LockOutput a generated file, the hash of its content and the inputs it was generated from
+type LockOutput struct {
+	Path   string   `json:"path"`
+	Hash   string   `json:"hash"`
+	Inputs []string `json:"inputs"`
+}
+
+// LockFilePath returns the path of the lock file belonging to the generate file
+func LockFilePath(generateFile string) string {
+	return path.Join(path.Dir(generateFile), LockFileName)
+}
+
+// NewLockFile creates the lock file of a generate file from its results
+func NewLockFile(generateFile string, results GenerateResults) (*LockFile, error) {
+	generateFileContent, err := os.ReadFile(generateFile)
+	if err != nil {
+		return nil, err
+	}
+	rootDir := path.Dir(generateFile)
+
+	allInputs := Inputs{}
+	outputs := []LockOutput{}
+	for _, result := range results {
+		outputPath, err := filepath.Rel(rootDir, result.OutputFile)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, LockOutput{
+			Path:   filepath.ToSlash(outputPath),
+			Hash:   hashContent(result.Content),
+			Inputs: result.Inputs.Paths(),
+		})
+		allInputs.merge(result.Inputs)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Path < outputs[j].Path
+	})
+
+	inputs := []LockEntry{}
+	for _, inputPath := range allInputs.Paths() {
+		inputs = append(inputs, LockEntry{Path: inputPath, Hash: allInputs[inputPath]})
+	}
+
+	return &LockFile{
+		Version:      lockFileVersion,
+		GenerateFile: LockEntry{Path: path.Base(generateFile), Hash: hashContent(generateFileContent)},
+		Inputs:       inputs,
+		Outputs:      outputs,
+	}, nil
+}
+
+// ReadLockFile reads the lock file belonging to the generate file, or returns nil if there is none
+func ReadLockFile(generateFile string) (*LockFile, error) {
+	lockFilePath := LockFilePath(generateFile)
+	content, err := os.ReadFile(lockFilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lockFile := &LockFile{}
+	if err := json.Unmarshal(content, lockFile); err != nil {
+		return nil, fmt.Errorf("%s: %w", lockFilePath, err)
+	}
+	if lockFile.Version != lockFileVersion {
+		return nil, fmt.Errorf("%s: unsupported lock file version %d", lockFilePath, lockFile.Version)
+	}
+	return lockFile, nil
+}
+
+// Save writes the lock file next to the generate file, unless its content did not change
+func (l *LockFile) Save(generateFile string) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	lockFilePath := LockFilePath(generateFile)
+	if existing, err := os.ReadFile(lockFilePath); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return writeFileAtomic(lockFilePath, content, 0644)
+}
+
+// OutputFiles returns the paths of all outputs relative to the current directory
+func (l *LockFile) OutputFiles(generateFile string) []string {
+	outputFiles := []string{}
+	for _, output := range l.Outputs {
+		outputFiles = append(outputFiles, path.Join(path.Dir(generateFile), output.Path))
+	}
+	return outputFiles
+}
+
+// UpToDate checks if the generate file, its inputs and its outputs still match the lock file, so the
+// generate file does not need to be evaluated again. Modification times are not trusted, since checkouts
+// and restored caches do not preserve them, so the content hashes of all files are compared.
+func (l *LockFile) UpToDate(generateFile string) (bool, error) {
+	rootDir := path.Dir(generateFile)
+
+	entries := []LockEntry{l.GenerateFile}
+	entries = append(entries, l.Inputs...)
+	for _, output := range l.Outputs {
+		entries = append(entries, LockEntry{Path: output.Path, Hash: output.Hash})
+	}
+
+	for _, entry := range entries {
+		content, err := os.ReadFile(path.Join(rootDir, entry.Path))
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if hashContent(content) != entry.Hash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// results returns a GenerateResult without content but with its inputs for every output of the lock file
+func (l *LockFile) results(generateFile string) GenerateResults {
+	hashes := map[string]string{}
+	for _, input := range l.Inputs {
+		hashes[input.Path] = input.Hash
+	}
+
+	results := GenerateResults{}
+	for i, outputFile := range l.OutputFiles(generateFile) {
+		result := NewGenerateResult(nil, outputFile)
+		result.Inputs = Inputs{}
+		for _, inputPath := range l.Outputs[i].Inputs {
+			result.Inputs[inputPath] = hashes[inputPath]
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,159 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// generatedFileMarker identifies files written by tf-generator, regardless of the comment style of the header
+const generatedFileMarker = "DO NOT EDIT! This file was generated by tf-generator."
+
+// OrphanError reports a generated file that no `generate{}` block produces anymore
+type OrphanError struct {
+	OutputFile string
+}
+
+func (e *OrphanError) Error() string {
+	return "the file was generated by tf-generator, but no generate block produces it anymore. Remove it with `tf-generator clean`."
+}
+
+func (e *OrphanError) ExitCode() int {
+	return ExitCodeOrphaned
+}
+
+// producedOutputs the outputs of all projects of a run, so no project reports a file of another one as orphaned
+type producedOutputs struct {
+	results map[string]bool // outputs of the evaluated `generate{}` blocks
+	locked  map[string]bool // outputs listed in the lock files, reported by the project owning the lock file
+}
+
+// newProducedOutputs collects the outputs of all evaluated projects and of all their lock files
+func newProducedOutputs(projects []*projectResult) (*producedOutputs, error) {
+	produced := &producedOutputs{results: map[string]bool{}, locked: map[string]bool{}}
+	for _, project := range projects {
+		for _, result := range project.results {
+			produced.results[canonicalPath(result.OutputFile)] = true
+		}
+		lockFile, err := ReadLockFile(project.filePath)
+		if err != nil {
+			return nil, err
+		}
+		if lockFile != nil {
+			for _, outputFile := range lockFile.OutputFiles(project.filePath) {
+				produced.locked[canonicalPath(outputFile)] = true
+			}
+		}
+	}
+	return produced, nil
+}
+
+// findOrphans returns all generated files of a project that no project of the run produces: outputs listed in
+// the lock file of the generate file, and files with the generated file marker in the directory of the generate
+// file. Other directories are never searched, since generate files outside of the run may produce files there.
+func findOrphans(generateFile string, produced *producedOutputs) ([]string, error) {
+	// Outputs of the last run that still exist
+	orphans := []string{}
+	lockFile, err := ReadLockFile(generateFile)
+	if err != nil {
+		return nil, err
+	}
+	if lockFile != nil {
+		for _, outputFile := range lockFile.OutputFiles(generateFile) {
+			if _, err := os.Stat(outputFile); err == nil && !produced.results[canonicalPath(outputFile)] {
+				orphans = append(orphans, outputFile)
+			}
+		}
+	}
+
+	rootDir := path.Dir(generateFile)
+	entries, err := os.ReadDir(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		filePath := path.Join(rootDir, entry.Name())
+		if !entry.Type().IsRegular() || produced.results[canonicalPath(filePath)] || produced.locked[canonicalPath(filePath)] {
+			continue
+		}
+
+		isGenerated, err := hasGeneratedFileMarker(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated {
+			orphans = append(orphans, filePath)
+		}
+	}
+	return orphans, nil
+}
+
+// hasGeneratedFileMarker checks if the first line of the file contains the generated file marker
+func hasGeneratedFileMarker(filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	firstLine, _, _ := bytes.Cut(head[:n], []byte("\n"))
+	return bytes.Contains(firstLine, []byte(generatedFileMarker)), nil
+}
+
+// Clean deletes all orphaned generated files of the given generate files. The orphans are only
+// deleted if confirm returns true.
+func Clean(filePaths []string, parallelism int, confirm func(orphans []string) (bool, error)) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no generate files found")
+	}
+
+	projects := make([]*projectResult, len(filePaths))
+	limit := newLimiter(parallelism)
+	forEachParallel(len(filePaths), cap(limit), func(i int) {
+		projects[i] = loadProject(filePaths[i], RunOptions{Parallelism: parallelism}, limit)
+	})
+
+	// Never delete anything unless all projects could be evaluated
+	for _, project := range projects {
+		if project.err != nil {
+			return fmt.Errorf("%s: %w", project.filePath, project.err)
+		}
+	}
+	produced, err := newProducedOutputs(projects)
+	if err != nil {
+		return err
+	}
+
+	orphans := []string{}
+	for _, project := range projects {
+		projectOrphans, err := findOrphans(project.filePath, produced)
+		if err != nil {
+			return err
+		}
+		orphans = append(orphans, projectOrphans...)
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned generated files found.")
+		return nil
+	}
+	if ok, err := confirm(orphans); err != nil || !ok {
+		return err
+	}
+
+	for _, orphan := range orphans {
+		fmt.Printf("deleting %s...\n", orphan)
+		if err := os.Remove(orphan); err != nil {
+			return err
+		}
+	}
+	fmt.Println("DONE")
+	return nil
+}
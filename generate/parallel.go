@@ -0,0 +1,54 @@
+package generate
+
+import (
+	"runtime"
+	"sync"
+)
+
+// forEachParallel calls fn for every index in [0, count) using at most parallelism goroutines.
+// A parallelism below 1 uses one goroutine per CPU. Callers store results by index so the
+// order of the output does not depend on scheduling.
+func forEachParallel(count int, parallelism int, fn func(i int)) {
+	if parallelism < 1 {
+		parallelism = runtime.NumCPU()
+	}
+	if parallelism > count {
+		parallelism = count
+	}
+
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// limiter bounds the number of evaluations running at once, shared by all generate files of a run so
+// files and their blocks evaluated concurrently do not multiply the parallelism
+type limiter chan struct{}
+
+// newLimiter returns a limiter for parallelism evaluations, or one per CPU if parallelism is below 1
+func newLimiter(parallelism int) limiter {
+	if parallelism < 1 {
+		parallelism = runtime.NumCPU()
+	}
+	return make(limiter, parallelism)
+}
+
+// run calls fn once a slot is free. fn must not wait for other calls of run, which could deadlock.
+func (l limiter) run(fn func()) {
+	l <- struct{}{}
+	defer func() { <-l }()
+	fn()
+}
@@ -0,0 +1,96 @@
+package generate
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"io"
+	"path"
+	"strconv"
+)
+
+// RenderOptions selects what the `render` command evaluates; exactly one of the options must be set
+type RenderOptions struct {
+	Block      string // 1-based index or output path of a `generate{}` block
+	Expression string // expression evaluated with the locals of the generate file, e.g. `local.a`
+}
+
+// Render evaluates a single `generate{}` block or an expression of the generate file and writes the content
+// to w instead of to the output file. Blocks are rendered exactly as they would be written, including the header.
+func Render(filePath string, options RenderOptions, w io.Writer) error {
+	generateFile, err := LoadGenerateFile(filePath)
+	if err != nil {
+		return err
+	}
+	if diags := generateFile.Locals.LoadAll(generateFile.GenerateContext); diags.HasErrors() {
+		return diags
+	}
+
+	var content []byte
+	if options.Expression != "" {
+		content, err = generateFile.renderExpression(options.Expression)
+	} else {
+		content, err = generateFile.renderBlock(options.Block)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// renderBlock evaluates the block with the given 1-based index or output path
+func (g *GenerateFile) renderBlock(block string) ([]byte, error) {
+	var selected *GenerateBlock
+	if index, err := strconv.Atoi(block); err == nil {
+		if index < 1 || index > len(g.GenerateBlocks) {
+			return nil, fmt.Errorf("%s has %d generate blocks, there is no block %d", g.FilePath, len(g.GenerateBlocks), index)
+		}
+		selected = g.GenerateBlocks[index-1]
+	} else {
+		for _, generateBlock := range g.GenerateBlocks {
+			if path.Clean(generateBlock.Output) == path.Clean(block) {
+				selected = generateBlock
+				break
+			}
+		}
+		if selected == nil {
+			return nil, fmt.Errorf("%s has no generate block with the output %s", g.FilePath, block)
+		}
+	}
+
+	result, diags := selected.Load(g.GenerateContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return result.Content, nil
+}
+
+// renderExpression evaluates an expression, which must result in a string or file content
+func (g *GenerateFile) renderExpression(expression string) ([]byte, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(expression), "<expr>", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	val, diags := expr.Value(g.GenerateContext.EvalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if content, err := convert.Convert(val, cty.String); err == nil && !content.IsNull() {
+		return []byte(content.AsString()), nil
+	}
+	if fileContent, err := convert.Convert(val, CtyFileContentType); err == nil && !fileContent.IsNull() {
+		return []byte(LoadFileContent(fileContent).Content), nil
+	}
+	return nil, hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid expression result",
+			Detail:   fmt.Sprintf("The expression must result in a string or file content, got %s.", val.Type().FriendlyName()),
+			Subject:  expr.Range().Ptr(),
+		},
+	}
+}
@@ -0,0 +1,164 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"io"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ResultRecord describes the outcome of a single GenerateResult, or of a generate file that
+// could not be evaluated at all, in which case Output is empty
+type ResultRecord struct {
+	Output       string             `json:"output"`
+	Status       ResultStatus       `json:"status"`
+	GenerateFile string             `json:"generate_file"`
+	Diagnostics  []DiagnosticRecord `json:"diagnostics"`
+	Diff         string             `json:"diff,omitempty"` // changes that would be written in a dry run
+	err          error
+}
+
+// DiagnosticRecord is the machine-readable form of an hcl.Diagnostic
+type DiagnosticRecord struct {
+	Severity string       `json:"severity"`
+	Summary  string       `json:"summary"`
+	Detail   string       `json:"detail,omitempty"`
+	Range    *RangeRecord `json:"range,omitempty"`
+}
+
+// RangeRecord is the machine-readable form of an hcl.Range
+type RangeRecord struct {
+	Filename string    `json:"filename"`
+	Start    PosRecord `json:"start"`
+	End      PosRecord `json:"end"`
+}
+
+// PosRecord is the machine-readable form of an hcl.Pos
+type PosRecord struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+func NewResultRecord(generateFile string, output string, status ResultStatus, err error) *ResultRecord {
+	return &ResultRecord{
+		Output:       output,
+		Status:       status,
+		GenerateFile: generateFile,
+		Diagnostics:  newDiagnosticRecords(err),
+		err:          err,
+	}
+}
+
+// newDiagnosticRecords converts an error to diagnostics; errors that are not hcl.Diagnostics
+// become a single diagnostic without a range
+func newDiagnosticRecords(err error) []DiagnosticRecord {
+	records := []DiagnosticRecord{}
+	if err == nil {
+		return records
+	}
+
+	diags, ok := err.(hcl.Diagnostics)
+	if !ok {
+		return append(records, DiagnosticRecord{
+			Severity: "error",
+			Summary:  err.Error(),
+		})
+	}
+	for _, diag := range diags {
+		record := DiagnosticRecord{
+			Severity: "error",
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+		}
+		if diag.Severity == hcl.DiagWarning {
+			record.Severity = "warning"
+		}
+		if diag.Subject != nil {
+			record.Range = &RangeRecord{
+				Filename: diag.Subject.Filename,
+				Start:    PosRecord{Line: diag.Subject.Start.Line, Column: diag.Subject.Start.Column, Byte: diag.Subject.Start.Byte},
+				End:      PosRecord{Line: diag.Subject.End.Line, Column: diag.Subject.End.Column, Byte: diag.Subject.End.Byte},
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Reporter prints the progress and outcome of Run
+type Reporter interface {
+	Loading(generateFile string)
+	Result(record *ResultRecord)
+	Done(records []*ResultRecord)
+}
+
+// NewReporter returns the Reporter for the given output format
+func NewReporter(format string, w io.Writer) (Reporter, error) {
+	switch format {
+	case FormatText:
+		return &textReporter{w: w}, nil
+	case FormatJSON:
+		return &jsonReporter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+// textReporter prints human-readable progress lines
+type textReporter struct {
+	w io.Writer
+}
+
+func (t *textReporter) Loading(generateFile string) {
+	fmt.Fprintf(t.w, "Loading %s and its references...\n", generateFile)
+}
+
+func (t *textReporter) Result(record *ResultRecord) {
+	if record.Output != "" {
+		fmt.Fprintf(t.w, "  %-9s %s\n", record.Status, record.Output)
+	}
+	if record.Diff != "" {
+		fmt.Fprint(t.w, record.Diff)
+	}
+}
+
+func (t *textReporter) Done(records []*ResultRecord) {
+	// Count results per status in a stable order
+	counts := map[ResultStatus]int{}
+	statuses := []ResultStatus{}
+	for _, record := range records {
+		if counts[record.Status] == 0 {
+			statuses = append(statuses, record.Status)
+		}
+		counts[record.Status]++
+	}
+	summary := []string{}
+	for _, status := range statuses {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+	}
+
+	if len(summary) == 0 {
+		fmt.Fprintln(t.w, "DONE")
+		return
+	}
+	fmt.Fprintf(t.w, "DONE: %s\n", strings.Join(summary, ", "))
+}
+
+// jsonReporter prints one JSON object per line for every ResultRecord
+type jsonReporter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonReporter) Loading(string) {}
+
+func (j *jsonReporter) Result(record *ResultRecord) {
+	_ = j.encoder.Encode(record)
+}
+
+func (j *jsonReporter) Done([]*ResultRecord) {}
@@ -1,36 +1,358 @@
 package generate
 
 import (
+	"errors"
 	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"io"
+	"os"
+	"path"
+	"strings"
 )
 
+// RunOptions configures the `generate` command
+type RunOptions struct {
+	Check       bool
+	Parallelism int       // maximum number of files evaluated at once, 0 for one per CPU
+	Format      string    // FormatText or FormatJSON, defaults to FormatText
+	DiffContext int       // number of unchanged lines shown around each change in check mode and dry runs
+	PatchFile   string    // if set in check mode, all differences are written to this file for `git apply`
+	Lock        bool      // write a lock file with all inputs and outputs next to each generate file
+	Incremental bool      // skip generate files whose lock file shows no changes to their inputs or outputs
+	DryRun      bool      // only report what would be written, with a diff of every change
+	Out         io.Writer // receives the report, defaults to os.Stdout
+}
+
+// projectResult holds the evaluated results of a single generate file
+type projectResult struct {
+	filePath string
+	results  GenerateResults
+	skipped  bool // the inputs did not change since the last run, results only contain the output files
+	err      error
+
+	dependencies []*projectResult // projects producing inputs of this project
+}
+
 // Run main entry point for the `generate` command
-func Run(filePath string, check bool) error {
-	fmt.Printf("Loading %s and its references...\n", filePath)
-	generateFile, err := LoadGenerateFile(filePath)
+func Run(filePaths []string, options RunOptions) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no generate files found")
+	}
+	if options.Format == "" {
+		options.Format = FormatText
+	}
+	if options.Out == nil {
+		options.Out = os.Stdout
+	}
+	reporter, err := NewReporter(options.Format, options.Out)
 	if err != nil {
 		return err
 	}
 
-	results, diags := generateFile.LoadAll()
-	if diags.HasErrors() {
+	// Evaluate all generate files concurrently; results are applied in order afterwards
+	projects := make([]*projectResult, len(filePaths))
+	limit := newLimiter(options.Parallelism)
+	forEachParallel(len(filePaths), cap(limit), func(i int) {
+		projects[i] = loadProject(filePaths[i], options, limit)
+	})
+
+	// Make sure no two projects write to the same file before anything is written
+	if diags := allResults(projects).CheckDuplicateOutputs(); diags.HasErrors() {
+		for _, diag := range diags {
+			reporter.Result(NewResultRecord(diag.Subject.Filename, "", StatusError, hcl.Diagnostics{diag}))
+		}
 		return diags
 	}
 
-	for _, result := range results {
-		if check {
-			fmt.Printf("checking the file contents of %s...\n", result.OutputFile)
-			if err := result.Check(); err != nil {
-				return err
+	// Projects loading the outputs of other projects are applied after them
+	projects, err = orderProjects(projects)
+	if err != nil {
+		return err
+	}
+
+	// Orphans are only reported if no project of the run produces them
+	var produced *producedOutputs
+	if options.Check {
+		if produced, err = newProducedOutputs(projects); err != nil {
+			return err
+		}
+	}
+
+	records := []*ResultRecord{}
+	for _, project := range projects {
+		// The project was evaluated with the previous outputs of the projects it depends on
+		if len(project.dependencies) > 0 && !options.Check && !options.DryRun {
+			project = loadProject(project.filePath, options, limit)
+		}
+
+		reporter.Loading(project.filePath)
+		projectRecords := applyProject(project, options, produced)
+		for _, record := range projectRecords {
+			reporter.Result(record)
+		}
+		records = append(records, projectRecords...)
+	}
+	reporter.Done(records)
+	if options.DryRun && options.Format == FormatText {
+		fmt.Fprintln(options.Out, "Dry run, no files were written.")
+	}
+
+	if options.Check && options.PatchFile != "" {
+		if err := writePatchFile(options.PatchFile, records); err != nil {
+			return err
+		}
+	}
+
+	return failuresError(records, len(projects))
+}
+
+// FailuresError combines the errors of all failed records of a run into one report
+type FailuresError struct {
+	Failures     []*ResultRecord
+	ProjectCount int
+}
+
+// failuresError returns the error reporting all failed records, or nil if nothing failed
+func failuresError(records []*ResultRecord, projectCount int) error {
+	failures := []*ResultRecord{}
+	for _, record := range records {
+		if record.err != nil {
+			failures = append(failures, record)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	// A single failure keeps its original error so diagnostics are not lost
+	if len(failures) == 1 && projectCount == 1 {
+		return failures[0].err
+	}
+	return &FailuresError{Failures: failures, ProjectCount: projectCount}
+}
+
+func (e *FailuresError) Error() string {
+	report := strings.Builder{}
+	failedProjects := map[string]bool{}
+	for _, record := range e.Failures {
+		location := record.Output
+		if location == "" {
+			location = record.GenerateFile
+		}
+		fmt.Fprintf(&report, "%s: %s\n\n", location, strings.TrimSuffix(record.err.Error(), "\n"))
+		failedProjects[record.GenerateFile] = true
+	}
+	fmt.Fprintf(&report, "%d failures in %d of %d projects", len(e.Failures), len(failedProjects), e.ProjectCount)
+	return report.String()
+}
+
+// ExitCode returns the exit code of the most severe failure: errors before drifted, missing, orphaned
+// and tampered outputs
+func (e *FailuresError) ExitCode() int {
+	exitCode := ExitCodeTampered
+	for _, record := range e.Failures {
+		var exitCoder interface{ ExitCode() int }
+		if !errors.As(record.err, &exitCoder) {
+			return ExitCodeError
+		}
+		if exitCoder.ExitCode() < exitCode {
+			exitCode = exitCoder.ExitCode()
+		}
+	}
+	return exitCode
+}
+
+// loadProject evaluates all `generate{}` blocks of a single generate file without touching the output files
+func loadProject(filePath string, options RunOptions, limit limiter) *projectResult {
+	project := &projectResult{filePath: filePath}
+
+	if options.Incremental {
+		lockFile, err := ReadLockFile(filePath)
+		if err != nil {
+			project.err = err
+			return project
+		}
+		if lockFile != nil {
+			upToDate, err := lockFile.UpToDate(filePath)
+			if err != nil {
+				project.err = err
+				return project
 			}
+			if upToDate {
+				project.results = lockFile.results(filePath)
+				project.skipped = true
+				return project
+			}
+		}
+	}
+
+	_, project.results, project.err = evaluateGenerateFile(filePath, limit)
+	return project
+}
+
+// evaluateGenerateFile loads a generate file and evaluates all its blocks, sharing the limiter with other files
+func evaluateGenerateFile(filePath string, limit limiter) (*GenerateFile, GenerateResults, error) {
+	var generateFile *GenerateFile
+	var err error
+	limit.run(func() {
+		generateFile, err = LoadGenerateFile(filePath)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	generateFile.GenerateContext.limiter = limit
+
+	results, diags := generateFile.LoadAll()
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	return generateFile, results, nil
+}
+
+// applyProject checks or saves the evaluated results of a single generate file. Check mode reports
+// every failed result and the orphans that no project produces, otherwise saving stops at the first failure.
+func applyProject(project *projectResult, options RunOptions, produced *producedOutputs) []*ResultRecord {
+	if project.err != nil {
+		return []*ResultRecord{NewResultRecord(project.filePath, "", StatusError, project.err)}
+	}
+
+	records := []*ResultRecord{}
+	for _, result := range project.results {
+		if project.skipped {
+			records = append(records, NewResultRecord(project.filePath, result.OutputFile, StatusSkipped, nil))
+			continue
+		}
+
+		var status ResultStatus
+		var diff string
+		var err error
+		if options.Check {
+			status, err = result.Check(options.DiffContext)
+		} else if options.DryRun {
+			status, diff, err = result.DryRun(options.DiffContext)
 		} else {
-			fmt.Printf("updating %s)...\n", result.OutputFile)
-			if err := result.Save(); err != nil {
-				return err
+			status, err = result.Save()
+		}
+		record := NewResultRecord(project.filePath, result.OutputFile, status, err)
+		record.Diff = diff
+		records = append(records, record)
+		if err != nil && !options.Check {
+			return records
+		}
+	}
+
+	if options.Lock && !options.Check && !options.DryRun && !project.skipped {
+		if err := saveLockFile(project); err != nil {
+			records = append(records, NewResultRecord(project.filePath, LockFilePath(project.filePath), StatusError, err))
+		}
+	}
+
+	if options.Check {
+		orphans, err := findOrphans(project.filePath, produced)
+		if err != nil {
+			return append(records, NewResultRecord(project.filePath, "", StatusError, err))
+		}
+		for _, orphan := range orphans {
+			records = append(records, NewResultRecord(project.filePath, orphan, StatusOrphaned, &OrphanError{OutputFile: orphan}))
+		}
+	}
+	return records
+}
+
+// saveLockFile records the inputs and outputs of the project in its lock file
+func saveLockFile(project *projectResult) error {
+	lockFile, err := NewLockFile(project.filePath, project.results)
+	if err != nil {
+		return err
+	}
+	return lockFile.Save(project.filePath)
+}
+
+// writePatchFile writes the diffs of all drifted, tampered and missing outputs to a single patch file, which fixes the
+// drift when applied with `git apply`. An existing patch file is removed if nothing drifted.
+func writePatchFile(patchFile string, records []*ResultRecord) error {
+	patch := strings.Builder{}
+	for _, record := range records {
+		var driftErr *DriftError
+		var missingErr *MissingError
+		var tamperedErr *TamperedError
+		if errors.As(record.err, &driftErr) {
+			patch.WriteString(driftErr.Diff)
+		} else if errors.As(record.err, &tamperedErr) {
+			patch.WriteString(tamperedErr.Diff)
+		} else if errors.As(record.err, &missingErr) {
+			patch.WriteString(missingErr.Diff)
+		}
+	}
+
+	if patch.Len() == 0 {
+		if err := os.Remove(patchFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(patchFile, []byte(patch.String()), 0644)
+}
+
+// orderProjects sorts projects so that every project comes after the projects producing its inputs, keeping
+// the original order otherwise. Projects depending on each other cannot be generated and are reported.
+func orderProjects(projects []*projectResult) ([]*projectResult, error) {
+	producers := map[string]*projectResult{}
+	for _, project := range projects {
+		for _, result := range project.results {
+			producers[canonicalPath(result.OutputFile)] = project
+		}
+	}
+	for _, project := range projects {
+		project.dependencies = nil
+		seen := map[*projectResult]bool{project: true}
+		for _, result := range project.results {
+			for _, inputPath := range result.Inputs.Paths() {
+				producer := producers[canonicalPath(path.Join(path.Dir(project.filePath), inputPath))]
+				if producer != nil && !seen[producer] {
+					seen[producer] = true
+					project.dependencies = append(project.dependencies, producer)
+				}
+			}
+		}
+	}
+
+	ordered := []*projectResult{}
+	done := map[*projectResult]bool{}
+	for len(ordered) < len(projects) {
+		progress := false
+		for _, project := range projects {
+			if done[project] {
+				continue
 			}
+			ready := true
+			for _, dependency := range project.dependencies {
+				ready = ready && done[dependency]
+			}
+			if ready {
+				done[project] = true
+				ordered = append(ordered, project)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			cycle := []string{}
+			for _, project := range projects {
+				if !done[project] {
+					cycle = append(cycle, project.filePath)
+				}
+			}
+			return nil, fmt.Errorf("generate files load each other's outputs: %s", strings.Join(cycle, ", "))
 		}
 	}
+	return ordered, nil
+}
 
-	fmt.Println("DONE")
-	return nil
+// allResults returns the results of all successfully evaluated projects
+func allResults(projects []*projectResult) GenerateResults {
+	results := GenerateResults{}
+	for _, project := range projects {
+		results = append(results, project.results...)
+	}
+	return results
 }
@@ -0,0 +1,157 @@
+package generate
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"tf-generator/tf"
+)
+
+// Names of the outputs of a scaffolded generate file
+const (
+	scaffoldTfvarsOutput = "tf-generator.auto.tfvars"
+	scaffoldTfOutput     = "tf-generator.tf"
+)
+
+// ScaffoldOptions configures the `init` command
+type ScaffoldOptions struct {
+	Force   bool   // replace an existing generate file
+	RootDir string // last parent directory searched, defaults to the root of the git repository
+}
+
+// Scaffold writes a starter generate file to a terraform project. The `.tfvars` files of all parent
+// directories up to the root directory are merged into one `.tfvars` file, and their `.hcl` files are
+// combined into one `.tf` file. An existing generate file is only replaced if Force is set.
+func Scaffold(dirPath string, fileName string, options ScaffoldOptions) error {
+	filePath := path.Join(dirPath, fileName)
+	if _, err := os.Stat(filePath); err == nil && !options.Force {
+		return fmt.Errorf("%s already exists, use --force to replace it", filePath)
+	}
+	if info, err := os.Stat(dirPath); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	parentTfvars, parentHcl, err := scaffoldParentFiles(dirPath, fileName, options.RootDir)
+	if err != nil {
+		return err
+	}
+	if len(parentTfvars) == 0 && len(parentHcl) == 0 {
+		return fmt.Errorf("no .tfvars or .hcl files found in the parent directories of %s", dirPath)
+	}
+
+	// Keys the project already defines itself must not be defined twice
+	projectTfvars := []string{}
+	err = tf.IterateFilePaths(dirPath, []string{".tfvars"}, func(tfvarsPath string) error {
+		if path.Base(tfvarsPath) != scaffoldTfvarsOutput {
+			projectTfvars = append(projectTfvars, path.Base(tfvarsPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	content := strings.Builder{}
+	if len(parentTfvars) > 0 {
+		content.WriteString("# Shared .tfvars of the parent directories, the nearest directory takes precedence\n")
+		content.WriteString("generate {\n")
+		if len(projectTfvars) > 0 {
+			content.WriteString("content = remove-tfvar-keys(\n")
+			writeLoadList(&content, "merge-tfvars", parentTfvars, ",")
+			writeLoadList(&content, "merge-tfvars", projectTfvars, "")
+			content.WriteString(")\n")
+		} else {
+			content.WriteString("content = ")
+			writeLoadList(&content, "merge-tfvars", parentTfvars, "")
+		}
+		fmt.Fprintf(&content, "\noutput = %q\n", scaffoldTfvarsOutput)
+		content.WriteString("}\n")
+	}
+	if len(parentHcl) > 0 {
+		if len(parentTfvars) > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString("# Shared .hcl files of the parent directories\n")
+		content.WriteString("generate {\n")
+		content.WriteString("content = ")
+		writeLoadList(&content, "combine", parentHcl, "")
+		fmt.Fprintf(&content, "\noutput = %q\n", scaffoldTfOutput)
+		content.WriteString("}\n")
+	}
+
+	if err := os.WriteFile(filePath, hclwrite.Format([]byte(content.String())), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s. Run `tf-generator generate --file %s` to generate its outputs.\n", filePath, filePath)
+	return nil
+}
+
+// scaffoldParentFiles returns the `.tfvars` and `.hcl` files of all parent directories relative to dirPath,
+// nearest directory first. The walk stops at rootDir, or at the first directory containing `.git` if rootDir
+// is empty. Without either, it would pick up unrelated files up to the filesystem root, so that is an error.
+func scaffoldParentFiles(dirPath string, fileName string, rootDir string) ([]string, []string, error) {
+	absDirPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	absRootDir := ""
+	if rootDir != "" {
+		if absRootDir, err = filepath.Abs(rootDir); err != nil {
+			return nil, nil, err
+		}
+		if relPath, err := filepath.Rel(absRootDir, absDirPath); err != nil || strings.HasPrefix(relPath, "..") {
+			return nil, nil, fmt.Errorf("%s is not a parent directory of %s", rootDir, dirPath)
+		}
+	}
+
+	tfvarsPaths := []string{}
+	hclPaths := []string{}
+	for parentPath := absDirPath; ; {
+		if absRootDir != "" {
+			if parentPath == absRootDir {
+				break
+			}
+		} else if _, err := os.Stat(filepath.Join(parentPath, ".git")); err == nil {
+			break
+		}
+		next := filepath.Dir(parentPath)
+		if next == parentPath {
+			return nil, nil, fmt.Errorf("%s is not in a git repository, use --root to set the last parent directory searched", dirPath)
+		}
+		parentPath = next
+
+		err := tf.IterateFilePaths(parentPath, []string{".tfvars", ".hcl"}, func(filePath string) error {
+			if path.Base(filePath) == fileName || strings.HasPrefix(path.Base(filePath), ".") {
+				return nil
+			}
+			relPath, err := filepath.Rel(absDirPath, filePath)
+			if err != nil {
+				return err
+			}
+			if strings.HasSuffix(filePath, ".tfvars") {
+				tfvarsPaths = append(tfvarsPaths, filepath.ToSlash(relPath))
+			} else {
+				hclPaths = append(hclPaths, filepath.ToSlash(relPath))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return tfvarsPaths, hclPaths, nil
+}
+
+// writeLoadList writes a call of fn with a list that loads all files
+func writeLoadList(sb *strings.Builder, fn string, filePaths []string, suffix string) {
+	fmt.Fprintf(sb, "%s([\n", fn)
+	for _, filePath := range filePaths {
+		fmt.Fprintf(sb, "load(%q),\n", filePath)
+	}
+	fmt.Fprintf(sb, "])%s\n", suffix)
+}
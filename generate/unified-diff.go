@@ -0,0 +1,300 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultDiffContext number of unchanged lines shown around each change, same as `git diff`
+const DefaultDiffContext = 3
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine a single line of a line-based diff, including its trailing newline if it has one
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// UnifiedDiff returns a `git diff` style diff that changes oldContent into newContent. Paths are relative to
+// the top-level directory of the git repository, so the diff can be applied with `git apply` from anywhere
+// in the repository. A nil oldContent creates a new file. Returns an empty string if the contents are equal.
+func UnifiedDiff(filePath string, oldContent []byte, newContent []byte, contextLines int) (string, error) {
+	lines, err := diffLines(splitLines(string(oldContent)), splitLines(string(newContent)))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filePath, err)
+	}
+	hunks := diffHunks(lines, contextLines)
+	if len(hunks) == 0 && oldContent != nil {
+		return "", nil
+	}
+
+	diffPath := diffFilePath(filePath)
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", diffPath, diffPath)
+	if oldContent == nil {
+		sb.WriteString("new file mode 100644\n")
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&sb, "--- a/%s\n", diffPath)
+	}
+	fmt.Fprintf(&sb, "+++ b/%s\n", diffPath)
+
+	for _, hunk := range hunks {
+		writeHunk(&sb, lines, hunk[0], hunk[1])
+	}
+	return sb.String(), nil
+}
+
+// diffFilePath returns the slash-separated path used in diff headers, relative to the top-level directory of
+// the git repository, or to the current directory outside of a repository
+func diffFilePath(filePath string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(filePath))
+	}
+	baseDir := gitTopLevel(filepath.Dir(absPath))
+	if baseDir == "" {
+		if baseDir, err = os.Getwd(); err != nil {
+			return filepath.ToSlash(filepath.Clean(filePath))
+		}
+	}
+	relPath, err := filepath.Rel(baseDir, absPath)
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(filePath))
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// gitTopLevel returns the first directory containing `.git`, starting at dirPath, or an empty string
+func gitTopLevel(dirPath string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dirPath, ".git")); err == nil {
+			return dirPath
+		}
+		parentPath := filepath.Dir(dirPath)
+		if parentPath == dirPath {
+			return ""
+		}
+		dirPath = parentPath
+	}
+}
+
+// splitLines splits content into lines that keep their trailing newline
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a minimal line-based diff with Myers' algorithm in linear space
+func diffLines(oldLines []string, newLines []string) ([]diffLine, error) {
+	lines, err := myersDiff(make([]diffLine, 0, max(len(oldLines), len(newLines))), oldLines, newLines)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show the deleted lines of every change before the inserted ones, like `git diff`
+	for start := 0; start < len(lines); {
+		if lines[start].op == diffEqual {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && lines[end].op != diffEqual {
+			end++
+		}
+		sort.SliceStable(lines[start:end], func(i, j int) bool {
+			return lines[start+i].op == diffDelete && lines[start+j].op == diffInsert
+		})
+		start = end
+	}
+	return lines, nil
+}
+
+// myersDiff appends the diff of oldLines and newLines to lines. The middle snake of the shortest edit
+// script splits the problem in two halves, so only the furthest reaching paths have to be stored.
+func myersDiff(lines []diffLine, oldLines []string, newLines []string) ([]diffLine, error) {
+	// Common prefixes and suffixes are equal, which keeps the search small for typical changes
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		lines = append(lines, diffLine{op: diffEqual, text: oldLines[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	oldMiddle := oldLines[prefix : len(oldLines)-suffix]
+	newMiddle := newLines[prefix : len(newLines)-suffix]
+
+	switch {
+	case len(oldMiddle) == 0:
+		for _, line := range newMiddle {
+			lines = append(lines, diffLine{op: diffInsert, text: line})
+		}
+	case len(newMiddle) == 0:
+		for _, line := range oldMiddle {
+			lines = append(lines, diffLine{op: diffDelete, text: line})
+		}
+	default:
+		// Without a common prefix or suffix at least two edits are needed, so both halves are smaller
+		x, y, u, v, err := middleSnake(oldMiddle, newMiddle)
+		if err != nil {
+			return nil, err
+		}
+		if lines, err = myersDiff(lines, oldMiddle[:x], newMiddle[:y]); err != nil {
+			return nil, err
+		}
+		for _, line := range oldMiddle[x:u] {
+			lines = append(lines, diffLine{op: diffEqual, text: line})
+		}
+		if lines, err = myersDiff(lines, oldMiddle[u:], newMiddle[v:]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		lines = append(lines, diffLine{op: diffEqual, text: line})
+	}
+	return lines, nil
+}
+
+// middleSnake searches the shortest edit script from both ends at once and returns the snake
+// (x, y) to (u, v) where the two searches meet
+func middleSnake(oldLines []string, newLines []string) (int, int, int, int, error) {
+	n, m := len(oldLines), len(newLines)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+
+	// forward[offset+k] is the furthest x on diagonal k = x - y from the start, backward[offset+k]
+	// the furthest x on diagonal k from the end, counted backwards
+	offset := maxD + 1
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && oldLines[x] == newLines[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if reverseK := delta - k; odd && reverseK >= -(d-1) && reverseK <= d-1 && x+backward[offset+reverseK] >= n {
+				return startX, startY, x, y, nil
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && oldLines[n-1-x] == newLines[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if forwardK := delta - k; !odd && forwardK >= -d && forwardK <= d && x+forward[offset+forwardK] >= n {
+				return n - x, m - y, n - startX, m - startY, nil
+			}
+		}
+	}
+	return 0, 0, 0, 0, fmt.Errorf("no middle snake found in the diff of %d and %d lines", n, m)
+}
+
+// diffHunks groups changed lines with their surrounding context into [start, end) ranges of lines
+func diffHunks(lines []diffLine, contextLines int) [][2]int {
+	hunks := [][2]int{}
+	i := 0
+	for i < len(lines) {
+		if lines[i].op == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != diffEqual {
+				end++
+				continue
+			}
+			// Merge the next change into this hunk if the unchanged lines in between are shown anyway
+			next := end
+			for next < len(lines) && lines[next].op == diffEqual {
+				next++
+			}
+			if next == len(lines) || next-end > 2*contextLines {
+				end = min(end+contextLines, len(lines))
+				break
+			}
+			end = next
+		}
+		hunks = append(hunks, [2]int{start, end})
+		i = end
+	}
+	return hunks
+}
+
+// writeHunk writes the hunk header and lines of lines[start:end]
+func writeHunk(sb *strings.Builder, lines []diffLine, start int, end int) {
+	oldStart, newStart := 1, 1
+	for _, line := range lines[:start] {
+		if line.op != diffInsert {
+			oldStart++
+		}
+		if line.op != diffDelete {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, line := range lines[start:end] {
+		if line.op != diffInsert {
+			oldCount++
+		}
+		if line.op != diffDelete {
+			newCount++
+		}
+	}
+	// Empty ranges refer to the line before them
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, line := range lines[start:end] {
+		prefix := " "
+		if line.op == diffDelete {
+			prefix = "-"
+		} else if line.op == diffInsert {
+			prefix = "+"
+		}
+		sb.WriteString(prefix + strings.TrimSuffix(line.text, "\n") + "\n")
+		if !strings.HasSuffix(line.text, "\n") {
+			sb.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	header := "diff --git a/generate/out.txt b/generate/out.txt\n--- a/generate/out.txt\n+++ b/generate/out.txt\n"
+	for _, test := range []struct {
+		name         string
+		oldContent   []byte
+		newContent   string
+		expectedDiff string
+	}{
+		{
+			name:         "equal",
+			oldContent:   []byte("a\nb\nc\n"),
+			newContent:   "a\nb\nc\n",
+			expectedDiff: "",
+		},
+		{
+			name:         "new file",
+			oldContent:   nil,
+			newContent:   "a\nb\n",
+			expectedDiff: "diff --git a/generate/out.txt b/generate/out.txt\nnew file mode 100644\n--- /dev/null\n+++ b/generate/out.txt\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:         "changed line",
+			oldContent:   []byte("a\nb\nc\nd\ne\nf\ng\n"),
+			newContent:   "a\nb\nc\nD\ne\nf\ng\n",
+			expectedDiff: header + "@@ -3,3 +3,3 @@\n c\n-d\n+D\n e\n",
+		},
+		{
+			name:         "appended line",
+			oldContent:   []byte("a\nb\nc\n"),
+			newContent:   "a\nb\nc\nd\n",
+			expectedDiff: header + "@@ -3,1 +3,2 @@\n c\n+d\n",
+		},
+		{
+			name:         "removed line",
+			oldContent:   []byte("a\nb\nc\n"),
+			newContent:   "b\nc\n",
+			expectedDiff: header + "@@ -1,2 +1,1 @@\n-a\n b\n",
+		},
+		{
+			name:         "no newline at end of file",
+			oldContent:   []byte("a\nb"),
+			newContent:   "a\nc",
+			expectedDiff: header + "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name:         "separate hunks",
+			oldContent:   []byte("1\n2\n3\n4\n5\n"),
+			newContent:   "x\n2\n3\n4\ny\n",
+			expectedDiff: header + "@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -4,2 +4,2 @@\n 4\n-5\n+y\n",
+		},
+		{
+			name:         "reversed lines",
+			oldContent:   []byte("a\nb\nc\nd\n"),
+			newContent:   "d\nc\nb\na\n",
+			expectedDiff: header + "@@ -1,4 +1,4 @@\n-a\n-b\n-c\n d\n+c\n+b\n+a\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := UnifiedDiff("out.txt", test.oldContent, []byte(test.newContent), 1)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedDiff, diff)
+		})
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	oldLines := splitLines("a\nb\nc\na\nb\nb\na\n")
+	newLines := splitLines("c\nb\na\nb\na\nc\n")
+	lines, err := diffLines(oldLines, newLines)
+	assert.Nil(t, err)
+
+	// The classic example of Myers' paper needs 5 edits
+	edits := 0
+	for _, line := range lines {
+		if line.op != diffEqual {
+			edits++
+		}
+	}
+	assert.Equal(t, 5, edits)
+}
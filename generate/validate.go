@@ -0,0 +1,184 @@
+package generate
+
+import (
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"io"
+	"os"
+	"path"
+)
+
+// ValidateAll validates all generate files and writes every diagnostic found and the summary to w
+func ValidateAll(filePaths []string, w io.Writer) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no generate files found")
+	}
+
+	// The parser only provides the sources for code snippets in diagnostics
+	parser := hclparse.NewParser()
+	diagWriter := hcl.NewDiagnosticTextWriter(w, parser.Files(), 78, false)
+	failed := 0
+	for _, filePath := range filePaths {
+		diags := ValidateFile(filePath)
+		if len(diags) > 0 {
+			parser.ParseHCLFile(filePath)
+			if err := diagWriter.WriteDiagnostics(diags); err != nil {
+				return err
+			}
+		}
+		if diags.HasErrors() {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d generate files are invalid", failed, len(filePaths))
+	}
+	fmt.Fprintf(w, "Success! %d generate files are valid.\n", len(filePaths))
+	return nil
+}
+
+// ValidateFile statically checks a generate file without evaluating its `generate{}` blocks
+// or touching any output files
+func ValidateFile(filePath string) hcl.Diagnostics {
+	generateFile, err := LoadGenerateFile(filePath)
+	if err != nil {
+		if diags, ok := err.(hcl.Diagnostics); ok {
+			return diags
+		}
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read configuration",
+				Detail:   err.Error(),
+			},
+		}
+	}
+	return generateFile.Validate()
+}
+
+// Validate reports unknown functions, invalid function arguments, undefined or cyclic locals,
+// missing `load()` targets and duplicate outputs, all at once.
+func (g *GenerateFile) Validate() hcl.Diagnostics {
+
+	// Collect locals of all `locals{}` blocks
+	attributes, diags := g.Locals.attributes()
+	definedLocals := map[string]*hcl.Attribute{}
+	for _, attr := range attributes {
+		definedLocals[attr.Name] = attr
+	}
+
+	// Check all expressions
+	typeCheckContext := g.GenerateContext.typeCheckEvalContext()
+	for _, attr := range attributes {
+		diags = append(diags, g.validateExpression(attr.Expr, definedLocals, typeCheckContext)...)
+	}
+	diags = append(diags, newLocalsGraph(attributes).cycles()...)
+	headerContext := typeCheckContext.NewChild()
+	headerContext.Variables = map[string]cty.Value{"generator": cty.DynamicVal}
+	for _, block := range g.GenerateBlocks {
+		diags = append(diags, g.validateExpression(block.Content, definedLocals, typeCheckContext)...)
+		if block.Header != nil && !isNullExpression(block.Header) {
+			diags = append(diags, g.validateExpression(block.Header, definedLocals, headerContext)...)
+		}
+	}
+
+	diags = append(diags, g.GenerateBlocks.checkDuplicateOutputs(g.GenerateContext)...)
+
+	return diags
+}
+
+// validateExpression checks references to locals and `load()` targets, and evaluates the expression
+// with placeholder values to find unknown functions and invalid function arguments
+func (g *GenerateFile) validateExpression(expr hcl.Expression, definedLocals map[string]*hcl.Attribute, typeCheckContext *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	for _, variable := range expr.Variables() {
+		if variable.RootName() != "local" {
+			continue // Unknown variables are reported when evaluating the expression
+		}
+		refName, ok := localRefName(variable)
+		if !ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference",
+				Detail:   "A reference to a local value must be followed by the name of the local, e.g. `local.my-local`.",
+				Subject:  variable.SourceRange().Ptr(),
+			})
+			continue
+		}
+		if _, ok := definedLocals[refName]; !ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Reference to undeclared local value",
+				Detail:   fmt.Sprintf("A local value with the name %q has not been declared.", refName),
+				Subject:  variable.SourceRange().Ptr(),
+			})
+		}
+	}
+
+	if syntaxExpr, ok := expr.(hclsyntax.Expression); ok {
+		hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && call.Name == "load" && len(call.Args) == 1 {
+				diags = append(diags, g.validateLoadTarget(call.Args[0])...)
+			}
+			return nil
+		})
+	}
+
+	_, valueDiags := expr.Value(typeCheckContext)
+	return append(diags, valueDiags...)
+}
+
+// validateLoadTarget checks that the file loaded by `load()` exists, if its path is known statically
+func (g *GenerateFile) validateLoadTarget(arg hcl.Expression) hcl.Diagnostics {
+	if len(arg.Variables()) > 0 {
+		return nil
+	}
+	val, diags := arg.Value(nil)
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return nil
+	}
+
+	sourcePath := val.AsString()
+	if _, err := os.Stat(path.Join(g.GenerateContext.RootDir, sourcePath)); err != nil {
+		return hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Missing load() target",
+				Detail:   fmt.Sprintf("The file %q cannot be loaded: %s.", sourcePath, err),
+				Subject:  arg.Range().Ptr(),
+			},
+		}
+	}
+	return nil
+}
+
+// typeCheckEvalContext returns an EvalContext with the same functions as the generate context, which
+// only check their arguments and return unknown values instead of loading any files.
+// Locals are unknown values of any type, references to locals are checked separately.
+func (gc *GenerateContext) typeCheckEvalContext() *hcl.EvalContext {
+	functions := map[string]function.Function{}
+	for name, f := range gc.EvalContext.Functions {
+		f := f
+		functions[name] = function.New(&function.Spec{
+			Params:   f.Params(),
+			VarParam: f.VarParam(),
+			Type:     f.ReturnTypeForValues,
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.UnknownVal(retType), nil
+			},
+		})
+	}
+
+	return &hcl.EvalContext{
+		Functions: functions,
+		Variables: map[string]cty.Value{
+			"local": cty.DynamicVal,
+		},
+	}
+}
@@ -0,0 +1,249 @@
+package generate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+)
+
+// DefaultWatchInterval how often Watch checks the watched files for changes
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// WatchOptions configures the `watch` command
+type WatchOptions struct {
+	Parallelism int           // maximum number of files and blocks evaluated at once, 0 for one per CPU
+	Interval    time.Duration // how often the watched files are checked for changes
+}
+
+// watchedProject remembers the inputs of every `generate{}` block of a generate file from its last run
+type watchedProject struct {
+	filePath    string
+	blockInputs []Inputs
+	failed      bool // the last run failed, so the inputs of the blocks may be incomplete
+}
+
+// watchedFile the state of a watched file when it was last checked
+type watchedFile struct {
+	modTime int64
+	size    int64
+	exists  bool
+}
+
+// watcher regenerates the outputs of generate files whenever one of their inputs changes
+type watcher struct {
+	projects []*watchedProject
+	files    map[string]watchedFile
+	options  WatchOptions
+	reporter Reporter
+	errors   io.Writer
+}
+
+// Watch generates all files once, then polls the generate files and every file they read through `load()`.
+// Only the `generate{}` blocks depending on a changed file are evaluated again; a changed generate file
+// is evaluated completely. Watch runs until stop is closed.
+func Watch(filePaths []string, options WatchOptions, stop <-chan struct{}) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no generate files found")
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultWatchInterval
+	}
+
+	w := &watcher{
+		files:    map[string]watchedFile{},
+		options:  options,
+		reporter: &textReporter{w: os.Stdout},
+		errors:   os.Stderr,
+	}
+	written := map[string]bool{}
+	for _, filePath := range filePaths {
+		project := &watchedProject{filePath: filePath}
+		w.projects = append(w.projects, project)
+		w.regenerate(project, nil, written)
+	}
+	w.regenerateChanged(written)
+	w.updateFiles()
+	fmt.Printf("Watching %d files for changes...\n", len(w.files))
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll regenerates the affected blocks of all projects with changed files
+func (w *watcher) poll() {
+	w.regenerateChanged(w.changedFiles())
+	w.updateFiles()
+}
+
+// regenerateChanged regenerates the affected blocks of all projects watching one of the changed files. Outputs
+// written by one project may be loaded by another, so they are handled like changed files until nothing changes.
+func (w *watcher) regenerateChanged(changed map[string]bool) {
+	for round := 0; len(changed) > 0 && round <= len(w.projects); round++ {
+		written := map[string]bool{}
+		for _, project := range w.projects {
+			if !changed[project.filePath] && !project.failed {
+				if blocks := project.affectedBlocks(changed); len(blocks) > 0 {
+					w.regenerate(project, blocks, written)
+				}
+				continue
+			}
+			for filePath := range changed {
+				if project.watches(filePath) {
+					w.regenerate(project, nil, written)
+					break
+				}
+			}
+		}
+		changed = written
+	}
+}
+
+// regenerate evaluates and saves the given blocks of the project, or all blocks if blocks is nil.
+// The paths of all created and updated outputs are added to written.
+func (w *watcher) regenerate(project *watchedProject, blocks []int, written map[string]bool) {
+	w.reporter.Loading(project.filePath)
+	records := []*ResultRecord{}
+	report := func(record *ResultRecord) {
+		w.reporter.Result(record)
+		records = append(records, record)
+		if record.err != nil {
+			fmt.Fprintln(w.errors, record.err)
+		}
+	}
+	defer func() {
+		w.reporter.Done(records)
+	}()
+
+	generateFile, err := LoadGenerateFile(project.filePath)
+	if err != nil {
+		project.failed = true
+		report(NewResultRecord(project.filePath, "", StatusError, err))
+		return
+	}
+	generateFile.GenerateContext.Parallelism = w.options.Parallelism
+
+	// Blocks may have been added or removed if the generate file changed. Failed blocks keep their
+	// previous inputs, so they are evaluated again when one of them is fixed.
+	blockInputs := project.blockInputs
+	if blocks == nil || len(generateFile.GenerateBlocks) != len(blockInputs) {
+		blockInputs = make([]Inputs, len(generateFile.GenerateBlocks))
+		copy(blockInputs, project.blockInputs)
+		blocks = make([]int, len(generateFile.GenerateBlocks))
+		for i := range blocks {
+			blocks[i] = i
+		}
+	}
+
+	// Locals may depend on changed files as well, so they are always evaluated again
+	if diags := generateFile.Locals.LoadAll(generateFile.GenerateContext); diags.HasErrors() {
+		project.failed = true
+		report(NewResultRecord(project.filePath, "", StatusError, diags))
+		return
+	}
+	if diags := generateFile.GenerateBlocks.checkDuplicateOutputs(generateFile.GenerateContext); diags.HasErrors() {
+		project.failed = true
+		report(NewResultRecord(project.filePath, "", StatusError, diags))
+		return
+	}
+
+	results := make(GenerateResults, len(blocks))
+	errs := make([]error, len(blocks))
+	forEachParallel(len(blocks), w.options.Parallelism, func(i int) {
+		result, diags := generateFile.GenerateBlocks[blocks[i]].Load(generateFile.GenerateContext)
+		if diags.HasErrors() {
+			errs[i] = diags
+			return
+		}
+		results[i] = result
+	})
+
+	project.failed = false
+	for i, block := range blocks {
+		if errs[i] != nil {
+			project.failed = true
+			report(NewResultRecord(project.filePath, "", StatusError, errs[i]))
+			continue
+		}
+		blockInputs[block] = results[i].Inputs
+		status, err := results[i].Save()
+		if status == StatusCreated || status == StatusUpdated {
+			written[results[i].OutputFile] = true
+		}
+		report(NewResultRecord(project.filePath, results[i].OutputFile, status, err))
+	}
+	project.blockInputs = blockInputs
+}
+
+// affectedBlocks returns the indexes of all blocks that read one of the changed files
+func (p *watchedProject) affectedBlocks(changed map[string]bool) []int {
+	blocks := []int{}
+	for i, inputs := range p.blockInputs {
+		for sourcePath := range inputs {
+			if changed[path.Join(path.Dir(p.filePath), sourcePath)] {
+				blocks = append(blocks, i)
+				break
+			}
+		}
+	}
+	return blocks
+}
+
+// watches checks if the file is the generate file or one of the inputs of the project
+func (p *watchedProject) watches(filePath string) bool {
+	for _, watchedPath := range p.watchedFiles() {
+		if watchedPath == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+// watchedFiles returns the generate file and the inputs of all blocks
+func (p *watchedProject) watchedFiles() []string {
+	filePaths := []string{p.filePath}
+	for _, inputs := range p.blockInputs {
+		for sourcePath := range inputs {
+			filePaths = append(filePaths, path.Join(path.Dir(p.filePath), sourcePath))
+		}
+	}
+	return filePaths
+}
+
+// changedFiles returns all watched files whose modification time or size changed since they were last checked
+func (w *watcher) changedFiles() map[string]bool {
+	changed := map[string]bool{}
+	for filePath, last := range w.files {
+		if current := statWatchedFile(filePath); current != last {
+			changed[filePath] = true
+		}
+	}
+	return changed
+}
+
+// updateFiles records the current state of the watched files of all projects
+func (w *watcher) updateFiles() {
+	w.files = map[string]watchedFile{}
+	for _, project := range w.projects {
+		for _, filePath := range project.watchedFiles() {
+			w.files[filePath] = statWatchedFile(filePath)
+		}
+	}
+}
+
+func statWatchedFile(filePath string) watchedFile {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return watchedFile{}
+	}
+	return watchedFile{modTime: info.ModTime().UnixNano(), size: info.Size(), exists: true}
+}
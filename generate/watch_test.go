@@ -0,0 +1,75 @@
+package generate
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPoll(t *testing.T) {
+	dirPath := copyFixture(t, "../fixtures/valid/dependents")
+	errors := &bytes.Buffer{}
+	w := &watcher{
+		files:    map[string]watchedFile{},
+		reporter: &textReporter{w: io.Discard},
+		errors:   errors,
+	}
+	for _, project := range []string{"chained", "direct", "unrelated", "via-local"} {
+		w.projects = append(w.projects, &watchedProject{filePath: path.Join(dirPath, project, "tf-generator.hcl")})
+	}
+	for _, project := range w.projects {
+		w.regenerate(project, nil, map[string]bool{})
+	}
+	w.updateFiles()
+
+	// Only the block loading the shared file is affected by it
+	commonPath := path.Join(dirPath, "global", "common.tfvars")
+	changed := map[string]bool{commonPath: true}
+	assert.Equal(t, []int{0}, w.projects[1].affectedBlocks(changed))
+	assert.Equal(t, []int{}, w.projects[2].affectedBlocks(changed))
+	assert.Equal(t, []int{0}, w.projects[3].affectedBlocks(changed))
+
+	assert.Nil(t, os.WriteFile(commonPath, []byte("region = \"northeurope\"\n"), 0644))
+	w.poll()
+	assert.Empty(t, errors.String())
+
+	// chained/ loads the output of direct/, which was written by the watcher itself
+	for _, output := range []string{"chained/chained.auto.tfvars", "direct/common.auto.tfvars", "via-local/common.auto.tfvars"} {
+		content, err := os.ReadFile(path.Join(dirPath, output))
+		assert.Nil(t, err)
+		assert.Contains(t, string(content), "northeurope", output)
+	}
+	assert.Empty(t, w.changedFiles())
+}
+
+// copyFixture copies a fixture directory to a temporary directory, so a test can change it
+func copyFixture(t *testing.T, fixturePath string) string {
+	dirPath := t.TempDir()
+	err := filepath.WalkDir(fixturePath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(fixturePath, filePath)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dirPath, relPath), 0755)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dirPath, relPath), content, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dirPath
+}
@@ -5,7 +5,6 @@ go 1.21.4
 require (
 	github.com/hashicorp/hcl/v2 v2.19.1
 	github.com/musukvl/tfvars-parser v0.0.0-20230214134007-bfb82934219e
-	github.com/sergi/go-diff v1.3.1
 	github.com/stretchr/testify v1.4.0
 	github.com/zclconf/go-cty v1.14.2
 )
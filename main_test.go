@@ -1,20 +1,28 @@
 package main
 
 import (
+	"errors"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
 	"path"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 type ValidFixture struct {
 	dirPath string
+	args    []string
 }
 
 type InvalidFixture struct {
 	dirPath                 string
+	args                    []string
 	expectedMessageContains string
 	isDiag                  bool
+	expectedExitCode        int
 }
 
 func TestValidFixtures(t *testing.T) {
@@ -82,10 +90,70 @@ func TestValidFixtures(t *testing.T) {
 		{
 			dirPath: "fixtures/valid/locals-referencing-locals/",
 		},
+		{
+			dirPath: "fixtures/valid/locals-across-blocks/",
+		},
+		{
+			dirPath: "fixtures/valid/parallel-inject/",
+			args:    []string{"generate", "--file", "fixtures/valid/parallel-inject/tf-generator.hcl", "--check", "--parallelism", "8"},
+		},
+		{
+			dirPath: "fixtures/valid/format-output/",
+		},
+		{
+			dirPath: "fixtures/valid/custom-header/",
+		},
+		{
+			dirPath: "fixtures/valid/custom-header/",
+			args:    []string{"render", "--block", "output.md", "fixtures/valid/custom-header/"},
+		},
+		{
+			dirPath: "fixtures/valid/recursive/",
+			args:    []string{"generate", "--recursive", "--check", "fixtures/valid/recursive/"},
+		},
+		{
+			dirPath: "fixtures/valid/recursive/",
+			args:    []string{"validate", "--recursive", "fixtures/valid/recursive/"},
+		},
+		{
+			dirPath: "fixtures/valid/recursive/",
+			args:    []string{"graph", "--recursive", "--format", "json", "fixtures/valid/recursive/"},
+		},
+		{
+			dirPath: "fixtures/valid/incremental/",
+			args:    []string{"generate", "--file", "fixtures/valid/incremental/tf-generator.hcl", "--check", "--incremental"},
+		},
+		{
+			dirPath: "fixtures/valid/cross-project-outputs/",
+			args:    []string{"clean", "--recursive", "--yes", "fixtures/valid/cross-project-outputs/"},
+		},
+		{
+			dirPath: "fixtures/valid/cross-project-outputs/",
+			args:    []string{"clean", "--yes", "fixtures/valid/cross-project-outputs/"},
+		},
+		{
+			dirPath: "fixtures/valid/cross-project-outputs/",
+			args:    []string{"generate", "--check", "fixtures/valid/cross-project-outputs/"},
+		},
+		{
+			dirPath: "fixtures/valid/cross-project-outputs/",
+			args:    []string{"generate", "--recursive", "--check", "fixtures/valid/cross-project-outputs/"},
+		},
+		{
+			dirPath: "fixtures/valid/dependents/",
+			args:    []string{"generate", "--recursive", "--check", "fixtures/valid/dependents/"},
+		},
+		{
+			dirPath: "fixtures/valid/header-inputs/",
+			args:    []string{"generate", "--file", "fixtures/valid/header-inputs/tf-generator.hcl", "--check", "--incremental"},
+		},
 		// TODO test variable reference hell 2
 	} {
 		t.Run(fixture.dirPath, func(t *testing.T) {
-			args := []string{"generate", "--file", path.Join(fixture.dirPath, "tf-generator.hcl"), "--check"}
+			args := fixture.args
+			if args == nil {
+				args = []string{"generate", "--file", path.Join(fixture.dirPath, "tf-generator.hcl"), "--check"}
+			}
 			if err := run(args); err != nil {
 				t.Fatal(err)
 			}
@@ -135,16 +203,237 @@ func TestInvalidFixtures(t *testing.T) {
 			expectedMessageContains: `tf-generator.hcl:6,3-8: local "a" already defined`,
 			isDiag:                  true,
 		},
+		{
+			dirPath:                 "fixtures/invalid/cyclic-locals/",
+			expectedMessageContains: "tf-generator.hcl:2,3-14: Cycle in locals; The locals reference each other in a cycle: local.a -> local.b -> local.c -> local.a.",
+			isDiag:                  true,
+		},
+		{
+			dirPath:                 "fixtures/invalid/duplicate-output/",
+			expectedMessageContains: "tf-generator.hcl:9,1-9: Duplicate output; The output fixtures/invalid/duplicate-output/output.txt is also generated by the block at fixtures/invalid/duplicate-output/tf-generator.hcl:1,1-9.",
+			isDiag:                  true,
+		},
+		{
+			dirPath:                 "fixtures/invalid/duplicate-output-across-files/",
+			args:                    []string{"generate", "--recursive", "fixtures/invalid/duplicate-output-across-files/"},
+			expectedMessageContains: "project2/tf-generator.hcl:1,1-9: Duplicate output; The output fixtures/invalid/duplicate-output-across-files/project1/output.txt is also generated by the block at fixtures/invalid/duplicate-output-across-files/project1/tf-generator.hcl:1,1-9.",
+			isDiag:                  true,
+		},
+		{
+			dirPath:                 "fixtures/invalid/multiple-drifts/",
+			expectedMessageContains: "2 failures in 1 of 1 projects",
+			isDiag:                  false,
+			expectedExitCode:        2,
+		},
+		{
+			dirPath:                 "fixtures/invalid/missing-output/",
+			expectedMessageContains: "the output file does not exist yet and would be created.\ndiff --git a/fixtures/invalid/missing-output/missing.txt b/fixtures/invalid/missing-output/missing.txt\nnew file mode 100644\n--- /dev/null\n+++ b/fixtures/invalid/missing-output/missing.txt\n@@ -0,0 +1,2 @@\n+#DO NOT EDIT! This file was generated by tf-generator.\n+would be created\n",
+			isDiag:                  false,
+			expectedExitCode:        3,
+		},
+		{
+			dirPath:                 "fixtures/invalid/orphaned-output/",
+			expectedMessageContains: "the file was generated by tf-generator, but no generate block produces it anymore.",
+			isDiag:                  false,
+			expectedExitCode:        4,
+		},
+		{
+			dirPath:                 "fixtures/invalid/orphaned-lock-output/",
+			expectedMessageContains: "the file was generated by tf-generator, but no generate block produces it anymore.",
+			isDiag:                  false,
+			expectedExitCode:        4,
+		},
+		{
+			dirPath:                 "fixtures/invalid/tampered-output/",
+			expectedMessageContains: "the output file was edited by hand, its content does not match the checksum in its header.",
+			isDiag:                  false,
+			expectedExitCode:        5,
+		},
+		{
+			dirPath:                 "fixtures/invalid/incremental-changed-input/",
+			args:                    []string{"generate", "--file", "fixtures/invalid/incremental-changed-input/tf-generator.hcl", "--check", "--incremental"},
+			expectedMessageContains: "-shared input\n+changed input\n",
+			isDiag:                  false,
+			expectedExitCode:        2,
+		},
+		{
+			dirPath:                 "fixtures/invalid/dependency-cycle/",
+			args:                    []string{"generate", "--recursive", "fixtures/invalid/dependency-cycle/"},
+			expectedMessageContains: "generate files load each other's outputs: fixtures/invalid/dependency-cycle/a/tf-generator.hcl, fixtures/invalid/dependency-cycle/b/tf-generator.hcl",
+			isDiag:                  false,
+		},
+		{
+			dirPath:                 "fixtures/invalid/validate-errors/",
+			args:                    []string{"validate", "fixtures/invalid/validate-errors/"},
+			expectedMessageContains: "1 of 1 generate files are invalid",
+			isDiag:                  false,
+		},
+		{
+			dirPath:                 "fixtures/invalid/unformatted/",
+			args:                    []string{"fmt", "--check", "fixtures/invalid/unformatted/"},
+			expectedMessageContains: "1 of 1 generate files are not formatted",
+			isDiag:                  false,
+		},
 		// TODO test injected tfvar does not exist 2
 	} {
 		t.Run(fixture.dirPath, func(t *testing.T) {
-			filePath := path.Join(fixture.dirPath, "tf-generator.hcl")
-			args := []string{"generate", "--file", filePath, "--check"}
+			args := fixture.args
+			if args == nil {
+				args = []string{"generate", "--file", path.Join(fixture.dirPath, "tf-generator.hcl"), "--check"}
+			}
 			err := run(args)
 			assert.NotNilf(t, err, "expected error")
 			assert.Contains(t, err.Error(), fixture.expectedMessageContains)
 			_, isDiag := err.(hcl.Diagnostics)
 			assert.Equal(t, fixture.isDiag, isDiag)
+			if fixture.expectedExitCode != 0 {
+				var exitCoder interface{ ExitCode() int }
+				assert.True(t, errors.As(err, &exitCoder), "expected error with exit code")
+				assert.Equal(t, fixture.expectedExitCode, exitCoder.ExitCode())
+			}
 		})
 	}
 }
+
+func TestSaveOutputs(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := filepath.Join(dirPath, "tf-generator.hcl")
+	outputPath := filepath.Join(dirPath, "output.txt")
+	targetPath := filepath.Join(dirPath, "target", "real.txt")
+	writeGenerateFile := func(content string) {
+		generateFile := "generate {\n  content = {\n    file-name = \"\"\n    content   = \"" + content + "\"\n  }\n  output = \"output.txt\"\n}\n"
+		assert.Nil(t, os.WriteFile(filePath, []byte(generateFile), 0644))
+	}
+	generate := func() {
+		assert.Nil(t, run([]string{"generate", "--file", filePath}))
+	}
+
+	// New outputs get the same mode as files written by os.WriteFile, which applies the umask
+	writeGenerateFile("first")
+	generate()
+	referencePath := filepath.Join(dirPath, "reference.txt")
+	assert.Nil(t, os.WriteFile(referencePath, nil, 0644))
+	reference, err := os.Stat(referencePath)
+	assert.Nil(t, err)
+	info, err := os.Stat(outputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, reference.Mode().Perm(), info.Mode().Perm())
+
+	// Unchanged outputs are not written again
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+	assert.Nil(t, os.Chtimes(outputPath, lastWeek, lastWeek))
+	generate()
+	info, err = os.Stat(outputPath)
+	assert.Nil(t, err)
+	assert.True(t, info.ModTime().Equal(lastWeek), "unchanged output was written")
+
+	// Changed outputs keep their mode
+	assert.Nil(t, os.Chmod(outputPath, 0600))
+	writeGenerateFile("second")
+	generate()
+	info, err = os.Stat(outputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	content, _ := os.ReadFile(outputPath)
+	assert.Contains(t, string(content), "second")
+
+	// Symlinked outputs replace the target of the link
+	assert.Nil(t, os.Mkdir(filepath.Dir(targetPath), 0755))
+	assert.Nil(t, os.Rename(outputPath, targetPath))
+	assert.Nil(t, os.Symlink(filepath.Join("target", "real.txt"), outputPath))
+	writeGenerateFile("third")
+	generate()
+	info, err = os.Lstat(outputPath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode().Type())
+	content, _ = os.ReadFile(targetPath)
+	assert.Contains(t, string(content), "third")
+}
+
+func TestDependentProjects(t *testing.T) {
+	dirPath := copyFixture(t, "fixtures/valid/dependents")
+	assert.Nil(t, os.WriteFile(filepath.Join(dirPath, "global", "common.tfvars"), []byte("region = \"westus\"\n"), 0644))
+
+	// chained/ loads the output of direct/, so it must see the new content in the same run
+	assert.Nil(t, run([]string{"generate", "--recursive", dirPath}))
+	assert.Nil(t, run([]string{"generate", "--recursive", "--check", dirPath}))
+	content, err := os.ReadFile(filepath.Join(dirPath, "chained", "chained.auto.tfvars"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "westus")
+}
+
+func TestHeaderInputs(t *testing.T) {
+	dirPath := copyFixture(t, "fixtures/valid/header-inputs")
+	filePath := filepath.Join(dirPath, "tf-generator.hcl")
+	assert.Nil(t, run([]string{"generate", "--lock", "--file", filePath}))
+	assert.Nil(t, os.WriteFile(filepath.Join(dirPath, "owner.txt"), []byte("owner-b"), 0644))
+
+	// owner.txt is only used by the header, the project must not be skipped anyway
+	assert.Nil(t, run([]string{"generate", "--incremental", "--file", filePath}))
+	content, err := os.ReadFile(filepath.Join(dirPath, "out.txt"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "# Owner: owner-b\n")
+}
+
+func TestInit(t *testing.T) {
+	dirPath := copyFixture(t, "fixtures/scaffold")
+	project := filepath.Join(dirPath, "team", "project")
+	project2 := filepath.Join(dirPath, "team", "project2")
+	assertGolden := func(filePath string, goldenPath string) {
+		content, err := os.ReadFile(filePath)
+		assert.Nil(t, err)
+		golden, err := os.ReadFile(goldenPath)
+		assert.Nil(t, err)
+		assert.Equal(t, string(golden), string(content))
+	}
+
+	// The temporary directory is not in a git repository, so the walk must be bounded explicitly
+	err := run([]string{"init", project})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not in a git repository, use --root")
+
+	// The keys of project.tfvars are removed from the shared .tfvars, the nearest directory takes precedence
+	assert.Nil(t, run([]string{"init", "--root", dirPath, project}))
+	assertGolden(filepath.Join(project, "tf-generator.hcl"), filepath.Join(project, "expected.hcl.golden"))
+	assert.Nil(t, run([]string{"generate", "--file", filepath.Join(project, "tf-generator.hcl")}))
+	assertGolden(filepath.Join(project, "tf-generator.auto.tfvars"), filepath.Join(project, "expected.auto.tfvars.golden"))
+	assertGolden(filepath.Join(project, "tf-generator.tf"), filepath.Join(dirPath, "versions.hcl.golden"))
+
+	// Without .tfvars of its own, the shared .tfvars are merged as they are
+	assert.Nil(t, run([]string{"init", "--root", dirPath, project2}))
+	assertGolden(filepath.Join(project2, "tf-generator.hcl"), filepath.Join(project2, "expected.hcl.golden"))
+
+	// An existing generate file is only replaced with --force
+	assert.Nil(t, os.WriteFile(filepath.Join(project2, "tf-generator.hcl"), []byte("# edited\n"), 0644))
+	err = run([]string{"init", "--root", dirPath, project2})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already exists, use --force to replace it")
+	assert.Nil(t, run([]string{"init", "--root", dirPath, "--force", project2}))
+	assertGolden(filepath.Join(project2, "tf-generator.hcl"), filepath.Join(project2, "expected.hcl.golden"))
+}
+
+// copyFixture copies a fixture directory to a temporary directory, so a test can change it
+func copyFixture(t *testing.T, fixturePath string) string {
+	dirPath := t.TempDir()
+	err := filepath.WalkDir(fixturePath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(fixturePath, filePath)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dirPath, relPath), 0755)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dirPath, relPath), content, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dirPath
+}
@@ -0,0 +1,81 @@
+package tf
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// parseCacheSize maximum number of parse results kept, so long-running processes like `watch` do not keep
+// every version of every file they ever parsed
+const parseCacheSize = 1024
+
+// parseCacheKey identifies parsed content by its hash, so a shared file is parsed once no matter which
+// path it was loaded with
+type parseCacheKey struct {
+	contentHash [sha256.Size]byte
+}
+
+type parseCacheEntry[T any] struct {
+	key   parseCacheKey
+	once  sync.Once
+	value T
+	err   error
+}
+
+// parseCache memoizes parse results, evicting the least recently used ones. It is safe for concurrent use.
+type parseCache[T any] struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[parseCacheKey]*list.Element
+	recent  *list.List // entries from the most to the least recently used
+}
+
+func newParseCache[T any](size int) *parseCache[T] {
+	return &parseCache[T]{
+		size:    size,
+		entries: map[parseCacheKey]*list.Element{},
+		recent:  list.New(),
+	}
+}
+
+// get returns the cached result for the content, or calls parse once and caches its result
+func (c *parseCache[T]) get(content []byte, parse func() (T, error)) (T, error) {
+	key := parseCacheKey{contentHash: sha256.Sum256(content)}
+
+	c.mutex.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.recent.MoveToFront(element)
+	} else {
+		element = c.recent.PushFront(&parseCacheEntry[T]{key: key})
+		c.entries[key] = element
+		if c.recent.Len() > c.size {
+			oldest := c.recent.Back()
+			c.recent.Remove(oldest)
+			delete(c.entries, oldest.Value.(*parseCacheEntry[T]).key)
+		}
+	}
+	entry := element.Value.(*parseCacheEntry[T])
+	c.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.value, entry.err = parse()
+	})
+	return entry.value, entry.err
+}
+
+var tfvarsCache = newParseCache[*Tfvars](parseCacheSize)
+
+// CachedTfvars behaves like NewTfvars, but parses identical content only once. The returned Tfvars is
+// shared and must not be modified, its FileName is the name the content was parsed with first.
+func CachedTfvars(fileName string, fileContent []byte) (*Tfvars, error) {
+	tfvars, err := tfvarsCache.get(fileContent, func() (*Tfvars, error) {
+		return NewTfvars(fileName, fileContent)
+	})
+	if err != nil {
+		// Parse again, so the error names the file of the caller
+		return NewTfvars(fileName, fileContent)
+	}
+	return tfvars, nil
+}
@@ -0,0 +1,39 @@
+package tf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCacheParsesContentOnce(t *testing.T) {
+	cache := newParseCache[string](2)
+	parses := 0
+	parse := func(content string) string {
+		value, err := cache.get([]byte(content), func() (string, error) {
+			parses++
+			return content, nil
+		})
+		assert.Nil(t, err)
+		return value
+	}
+
+	// The same file loaded through different relative paths has the same content
+	assert.Equal(t, "a = 1", parse("a = 1"))
+	assert.Equal(t, "a = 1", parse("a = 1"))
+	assert.Equal(t, 1, parses)
+
+	// The least recently used content is evicted
+	parse("b = 2")
+	parse("c = 3")
+	parse("a = 1")
+	assert.Equal(t, 4, parses)
+}
+
+func TestCachedTfvarsErrorsNameTheCaller(t *testing.T) {
+	_, err := CachedTfvars("../global.tfvars", []byte("a = "))
+	assert.NotNil(t, err)
+	_, err = CachedTfvars("../../global.tfvars", []byte("a = "))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "../../global.tfvars")
+}
@@ -57,8 +57,8 @@ func LoadTfvarsFile(filePath string) (*Tfvars, error) {
 	return NewTfvars(path.Base(filePath), file)
 }
 
-// IterateTfvarsPaths iterates over all .tfvars files in a directory
-func iterateTfvarsPaths(dirPath string, fn func(string) error) error {
+// IterateFilePaths iterates over all files in a directory whose name ends with one of the suffixes
+func IterateFilePaths(dirPath string, suffixes []string, fn func(string) error) error {
 	fileInfos, err := os.ReadDir(dirPath)
 	if err != nil {
 		return err
@@ -66,15 +66,26 @@ func iterateTfvarsPaths(dirPath string, fn func(string) error) error {
 
 	for _, f := range fileInfos {
 		fileName := f.Name()
-		if !f.IsDir() && strings.HasSuffix(fileName, ".tfvars") {
-			if err := fn(path.Join(dirPath, fileName)); err != nil {
-				return err
+		if f.IsDir() {
+			continue
+		}
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(fileName, suffix) {
+				if err := fn(path.Join(dirPath, fileName)); err != nil {
+					return err
+				}
+				break
 			}
 		}
 	}
 	return nil
 }
 
+// iterateTfvarsPaths iterates over all .tfvars files in a directory
+func iterateTfvarsPaths(dirPath string, fn func(string) error) error {
+	return IterateFilePaths(dirPath, []string{".tfvars"}, fn)
+}
+
 // LoadTfvarsListFromProject loads all `.tfvars` files from a terraform project
 func LoadTfvarsListFromProject(dirPath string) ([]*Tfvars, error) {
 	var allTfvars []*Tfvars